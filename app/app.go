@@ -1,56 +1,181 @@
 package app
 
 import (
+	"context"
 	"database/sql"
-	"fmt"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
 
 	_ "github.com/lib/pq"
 
+	"github.com/aniljaiswalcs/pismo/db/migrations"
 	"github.com/aniljaiswalcs/pismo/handler"
+	"github.com/aniljaiswalcs/pismo/model"
+	"github.com/aniljaiswalcs/pismo/pkg/config"
+	"github.com/aniljaiswalcs/pismo/pkg/fx"
+	"github.com/aniljaiswalcs/pismo/pkg/logging"
+	"github.com/aniljaiswalcs/pismo/pkg/metrics"
 	"github.com/aniljaiswalcs/pismo/repository/adapter"
 	"github.com/gorilla/mux"
 )
 
 func Start() {
 
-	db := getNewPullConnectionDb()
-	defer db.Close()
+	// records are JSON from the start; the configured level applies once the
+	// configuration is loaded
+	slog.SetDefault(logging.New(os.Stdout, config.LOG_INFO))
 
-	accountRepositoryPostgres := adapter.NewAccountRepositoryPostgres(db)
-	transactionRepositoryPostgres := adapter.NewTransactionRepositoryPostgres(db)
+	conf, err := config.Load()
+	if err != nil {
+		fatal("Start: invalid configuration", err)
+	}
+
+	slog.SetDefault(logging.New(os.Stdout, conf.LogLevel))
+
+	// SIGINT and SIGTERM cancel ctx, which stops the background jobs and
+	// starts the shutdown of the server
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	db, err := getNewPullConnectionDb(conf)
+	if err != nil {
+		fatal("Start: opening the database failed", err)
+	}
+	if err := waitForDatabase(ctx, db, conf.Database.ConnectTimeout.Duration(), databaseBackoff); err != nil {
+		fatal("Start: database not reachable", err)
+	}
+
+	metrics.RegisterDB(db, "pismo_api")
+
+	schemaVersion, err := migrations.LatestVersion()
+	if err != nil {
+		fatal("Start: reading the migrations failed", err)
+	}
+
+	timeouts := adapter.Timeouts{
+		Query:       conf.Timeouts.Query.Duration(),
+		Transaction: conf.Timeouts.Transaction.Duration(),
+	}
+
+	accountRepository := metrics.InstrumentAccountRepository(adapter.NewAccountRepositoryPostgres(db, timeouts))
+	dischargeStrategy, err := model.NewDischargeStrategy(conf.DischargeStrategy)
+	if err != nil {
+		fatal("Start: invalid discharge strategy", err)
+	}
+
+	rateProvider, err := getRateProvider(conf.FXRatesFile)
+	if err != nil {
+		fatal("Start: loading the exchange rates failed", err)
+	}
+
+	transactionRepository := metrics.InstrumentTransactionRepository(adapter.NewTransactionRepositoryPostgres(db, dischargeStrategy, rateProvider, timeouts))
+
+	idempotencyRepository := metrics.InstrumentIdempotencyRepository(adapter.NewIdempotencyRepositoryPostgres(db, timeouts, conf.Timeouts.Write.Duration()))
 
-	accountHandler := handler.NewAccountHandler(accountRepositoryPostgres)
-	transactionHandler := handler.NewTransactionHandler(transactionRepositoryPostgres)
+	operationTypeRepository := metrics.InstrumentOperationTypeRepository(adapter.NewOperationTypeRepositoryPostgres(db, timeouts))
+	loadOperationTypes(ctx, operationTypeRepository, model.OperationTypes)
 
-	//port := ":" + os.Getenv("API_PORT")
-	port := ":3000"
+	healthRepositoryPostgres := adapter.NewHealthRepositoryPostgres(db, timeouts)
 
-	router := mux.NewRouter().PathPrefix("/v1").Subrouter()
+	requestTimeout := conf.Timeouts.Request.Duration()
+	accountHandler := handler.NewAccountHandler(accountRepository, requestTimeout)
+	transactionHandler := handler.NewTransactionHandler(transactionRepository, requestTimeout)
+	idempotencyHandler := handler.NewIdempotencyHandler(idempotencyRepository)
+	operationTypeHandler := handler.NewOperationTypeHandler(operationTypeRepository, model.OperationTypes, requestTimeout)
+	healthHandler := handler.NewHealthHandler(healthRepositoryPostgres, schemaVersion, requestTimeout)
+
+	go sweepIdempotencyKeys(ctx, idempotencyRepository, time.Hour, conf.IdempotencyKeyTTL.Duration())
+	go refreshOperationTypes(ctx, operationTypeRepository, model.OperationTypes, 5*time.Minute)
+
+	port := ":" + strconv.Itoa(conf.Port)
+
+	rootRouter := mux.NewRouter()
+	rootRouter.Use(metrics.Middleware)
+
+	// routes to probes and metrics, outside of the versioned API
+	rootRouter.HandleFunc("/healthz", healthHandler.Liveness).Methods("GET")
+	rootRouter.HandleFunc("/readyz", healthHandler.Readiness).Methods("GET")
+	rootRouter.Handle("/metrics", metrics.Handler()).Methods("GET")
+
+	router := rootRouter.PathPrefix("/v1").Subrouter()
 
 	// routes to accounts
 	accountMux := router.PathPrefix("/accounts").Subrouter()
-	accountMux.HandleFunc("", accountHandler.CreateAccount).Methods("POST")
+	accountMux.HandleFunc("", idempotencyHandler.Middleware(accountHandler.CreateAccount)).Methods("POST")
+	accountMux.HandleFunc("", accountHandler.FindAccountByDocument).Methods("GET")
 	accountMux.HandleFunc("/{accountId:[0-9]+}", accountHandler.GetAccount).Methods("GET")
+	accountMux.HandleFunc("/{accountId:[0-9]+}/credit-limit", accountHandler.UpdateCreditLimit).Methods("PATCH")
+	accountMux.HandleFunc("/{accountId:[0-9]+}/status", accountHandler.UpdateStatus).Methods("PATCH")
+	accountMux.HandleFunc("/{accountId:[0-9]+}/transactions", transactionHandler.ListAccountTransactions).Methods("GET")
+	accountMux.HandleFunc("/{accountId:[0-9]+}/balance", transactionHandler.GetAccountBalance).Methods("GET")
 
 	// routes to transaction
 	transactionMux := router.PathPrefix("/transactions").Subrouter()
-	transactionMux.HandleFunc("", transactionHandler.CreateTransaction).Methods("POST")
-	transactionMux.HandleFunc("/{transactionid:[0-9]+}", transactionHandler.GetAccount).Methods("GET")
+	transactionMux.HandleFunc("", idempotencyHandler.Middleware(transactionHandler.CreateTransaction)).Methods("POST")
+	transactionMux.HandleFunc("/{transactionid:[0-9]+}", transactionHandler.GetTransaction).Methods("GET")
+	transactionMux.HandleFunc("/{transactionid:[0-9]+}/allocations", transactionHandler.GetPaymentAllocations).Methods("GET")
+
+	// routes to operation types
+	router.HandleFunc("/operation-types", operationTypeHandler.GetOperationTypes).Methods("GET")
+
+	// routes to administration, only served with a token to guard them
+	if conf.AdminToken != "" {
+		adminMux := router.PathPrefix("/admin").Subrouter()
+		adminMux.Use(handler.RequireBearerToken(conf.AdminToken))
+		adminMux.HandleFunc("/operation-types", operationTypeHandler.ListOperationTypes).Methods("GET")
+		adminMux.HandleFunc("/operation-types", operationTypeHandler.CreateOperationType).Methods("POST")
+	} else {
+		slog.Info("Start: admin endpoints disabled, set ADMIN_TOKEN to enable them")
+	}
+
+	server := &http.Server{
+		Addr:         port,
+		Handler:      logging.Middleware(rootRouter),
+		ReadTimeout:  conf.Timeouts.Read.Duration(),
+		WriteTimeout: conf.Timeouts.Write.Duration(),
+	}
+
+	listener, err := net.Listen("tcp", port)
+	if err != nil {
+		fatal("Start: listening failed", err)
+	}
+
+	slog.Info("Start: server listening", "address", listener.Addr().String())
 
-	fmt.Println("Server: localhost" + port)
+	if err := runServer(ctx, server, listener, conf.Timeouts.Shutdown.Duration(), db); err != nil {
+		fatal("Start: server stopped", err)
+	}
+}
 
-	http.Handle("/", router)
-	fmt.Println(http.ListenAndServe(port, nil))
+func fatal(message string, err error) {
+	slog.Error(message, "error", err)
+	os.Exit(1)
 }
 
-func getNewPullConnectionDb() *sql.DB {
+func getRateProvider(path string) (fx.RateProvider, error) {
 
-	connStr := os.Getenv("POSTGRESQL_URL")
-	db, err := sql.Open("postgres", connStr)
+	if path == "" {
+		return fx.NewStaticRateProvider(nil), nil
+	}
+
+	return fx.LoadRateFile(path)
+}
+
+func getNewPullConnectionDb(conf config.Config) (*sql.DB, error) {
+
+	db, err := sql.Open("postgres", conf.DatabaseURL)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
-	return db
+
+	db.SetMaxOpenConns(conf.Database.MaxOpenConns)
+	db.SetMaxIdleConns(conf.Database.MaxIdleConns)
+	db.SetConnMaxLifetime(conf.Database.ConnMaxLifetime.Duration())
+	return db, nil
 }
@@ -0,0 +1,61 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// pinger is the part of *sql.DB waitForDatabase needs.
+type pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// backoff doubles the wait between attempts, starting at initial and never
+// going past max.
+type backoff struct {
+	initial time.Duration
+	max     time.Duration
+}
+
+var databaseBackoff = backoff{initial: 500 * time.Millisecond, max: 10 * time.Second}
+
+func (b backoff) next(delay time.Duration) time.Duration {
+	if delay <= 0 {
+		return b.initial
+	}
+	if delay *= 2; delay > b.max {
+		return b.max
+	}
+	return delay
+}
+
+// waitForDatabase pings the database until it answers, backing off between
+// attempts, so the API does not start serving before Postgres is up. It gives
+// up after timeout or when ctx is done, returning the last ping error.
+func waitForDatabase(ctx context.Context, db pinger, timeout time.Duration, b backoff) error {
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var delay time.Duration
+	for attempt := 1; ; attempt++ {
+		err := db.PingContext(ctx)
+		if err == nil {
+			if attempt > 1 {
+				slog.InfoContext(ctx, "waitForDatabase: database reachable", "attempts", attempt)
+			}
+			return nil
+		}
+
+		delay = b.next(delay)
+		slog.WarnContext(ctx, "waitForDatabase: database not reachable yet", "attempt", attempt, "retry_in", delay.String(), "error", err)
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("database not reachable after %d attempts: %w", attempt, err)
+		case <-time.After(delay):
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// flakyDatabase refuses as many pings as failures, then answers.
+type flakyDatabase struct {
+	failures int
+	pings    int
+}
+
+var errConnectionRefused = errors.New("connection refused")
+
+func (d *flakyDatabase) PingContext(ctx context.Context) error {
+	d.pings++
+	if d.pings <= d.failures {
+		return errConnectionRefused
+	}
+	return nil
+}
+
+func TestWaitForDatabaseRetriesUntilTheDatabaseAnswers(t *testing.T) {
+	db := &flakyDatabase{failures: 3}
+
+	err := waitForDatabase(context.Background(), db, time.Second, backoff{initial: time.Millisecond, max: 2 * time.Millisecond})
+
+	if err != nil {
+		t.Errorf("Expected the database to be reached but got %v", err)
+	}
+	if db.pings != 4 {
+		t.Errorf("Expected 4 pings but got %d", db.pings)
+	}
+}
+
+func TestWaitForDatabaseGivesUpAfterTheTimeout(t *testing.T) {
+	db := &flakyDatabase{failures: 1 << 30}
+
+	err := waitForDatabase(context.Background(), db, 20*time.Millisecond, backoff{initial: time.Millisecond, max: 5 * time.Millisecond})
+
+	if !errors.Is(err, errConnectionRefused) {
+		t.Errorf("Expected the last ping error but got %v", err)
+	}
+	if db.pings < 2 {
+		t.Errorf("Expected the ping to be retried but got %d pings", db.pings)
+	}
+}
+
+func TestBackoffDoublesUpToTheMaximum(t *testing.T) {
+	b := backoff{initial: 500 * time.Millisecond, max: 3 * time.Second}
+
+	var delay time.Duration
+	for _, expected := range []time.Duration{500 * time.Millisecond, time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second} {
+		if delay = b.next(delay); delay != expected {
+			t.Errorf("Expected %s but got %s", expected, delay)
+		}
+	}
+}
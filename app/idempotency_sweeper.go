@@ -0,0 +1,33 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/aniljaiswalcs/pismo/repository"
+)
+
+// sweepIdempotencyKeys deletes idempotency keys older than ttl every interval
+// until ctx is done.
+func sweepIdempotencyKeys(ctx context.Context, repository repository.IdempotencyRepository, interval time.Duration, ttl time.Duration) {
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := repository.DeleteExpiredKeys(ctx, time.Now().Add(-ttl))
+			if err != nil {
+				slog.ErrorContext(ctx, "sweepIdempotencyKeys: deleting expired keys failed", "error", err)
+				continue
+			}
+			if deleted > 0 {
+				slog.InfoContext(ctx, "sweepIdempotencyKeys: deleted expired keys", "deleted", deleted)
+			}
+		}
+	}
+}
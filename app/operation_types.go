@@ -0,0 +1,40 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/aniljaiswalcs/pismo/model"
+	"github.com/aniljaiswalcs/pismo/repository"
+)
+
+// loadOperationTypes replaces the cached operation types with the ones stored
+// in the database. On failure the registry keeps what it had.
+func loadOperationTypes(ctx context.Context, repository repository.OperationTypeRepository, registry *model.OperationTypeRegistry) {
+
+	operationTypes, err := repository.ListOperationTypes(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "loadOperationTypes: loading operation types failed", "error", err)
+		return
+	}
+
+	registry.Load(operationTypes)
+}
+
+// refreshOperationTypes reloads the operation types every interval until ctx
+// is done, so types added through another instance become valid here too.
+func refreshOperationTypes(ctx context.Context, repository repository.OperationTypeRepository, registry *model.OperationTypeRegistry, interval time.Duration) {
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			loadOperationTypes(ctx, repository, registry)
+		}
+	}
+}
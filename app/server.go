@@ -0,0 +1,52 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+)
+
+// runServer serves on listener until ctx is done, then shuts down in order:
+// the server stops accepting connections and waits up to shutdownTimeout for
+// in-flight requests, and only then db is closed, so no request loses its
+// database half-way through. Requests still running after the deadline are
+// cut off and the deadline error is returned.
+func runServer(ctx context.Context, server *http.Server, listener net.Listener, shutdownTimeout time.Duration, db io.Closer) error {
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.Serve(listener)
+	}()
+
+	select {
+	case err := <-serveErr:
+		db.Close()
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("runServer: shutting down, waiting for in-flight requests", "shutdown_timeout", shutdownTimeout.String())
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	err := server.Shutdown(shutdownCtx)
+	if err != nil {
+		slog.Warn("runServer: in-flight requests did not finish", "error", err)
+		server.Close()
+	}
+
+	if serveErr := <-serveErr; !errors.Is(serveErr, http.ErrServerClosed) && err == nil {
+		err = serveErr
+	}
+
+	if closeErr := db.Close(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+
+	return err
+}
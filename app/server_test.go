@@ -0,0 +1,152 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// recordingCloser stands in for the database and reports when it is closed.
+type recordingCloser struct {
+	events chan string
+}
+
+func (c *recordingCloser) Close() error {
+	c.events <- "database closed"
+	return nil
+}
+
+func startServer(t *testing.T, handler http.HandlerFunc, shutdownTimeout time.Duration, events chan string) (string, context.CancelFunc, chan error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	result := make(chan error, 1)
+	go func() {
+		result <- runServer(ctx, &http.Server{Handler: handler}, listener, shutdownTimeout, &recordingCloser{events: events})
+	}()
+
+	return listener.Addr().String(), cancel, result
+}
+
+func TestRunServerDrainsRequestsBeforeClosingTheDatabase(t *testing.T) {
+	events := make(chan string, 10)
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	address, cancel, result := startServer(t, func(w http.ResponseWriter, req *http.Request) {
+		close(started)
+		<-release
+		events <- "request finished"
+		w.WriteHeader(http.StatusOK)
+	}, 5*time.Second, events)
+
+	responses := make(chan *http.Response, 1)
+	go func() {
+		response, err := http.Get("http://" + address)
+		if err != nil {
+			t.Errorf("Expected the in-flight request to finish but got %v", err)
+			close(responses)
+			return
+		}
+		responses <- response
+	}()
+
+	<-started
+	cancel()
+
+	// new connections are refused as soon as the shutdown starts
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		connection, err := net.Dial("tcp", address)
+		if err != nil {
+			break
+		}
+		connection.Close()
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the server to stop accepting connections")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	select {
+	case event := <-events:
+		t.Fatalf("Expected nothing to happen while the request runs but got %q", event)
+	case err := <-result:
+		t.Fatalf("Expected the server to wait for the request but it returned %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+
+	if response := <-responses; response == nil || response.StatusCode != http.StatusOK {
+		t.Errorf("Expected the in-flight request to succeed but got %v", response)
+	}
+	if err := <-result; err != nil {
+		t.Errorf("Expected a clean shutdown but got %v", err)
+	}
+
+	for _, expected := range []string{"request finished", "database closed"} {
+		if event := <-events; event != expected {
+			t.Errorf("Expected %q but got %q", expected, event)
+		}
+	}
+}
+
+func TestRunServerStopsWaitingAfterTheDeadline(t *testing.T) {
+	events := make(chan string, 10)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+
+	address, cancel, result := startServer(t, func(w http.ResponseWriter, req *http.Request) {
+		close(started)
+		select {
+		case <-release:
+		case <-req.Context().Done():
+		}
+	}, 50*time.Millisecond, events)
+
+	go http.Get("http://" + address)
+
+	<-started
+	cancel()
+
+	select {
+	case err := <-result:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected %v but got %v", context.DeadlineExceeded, err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the shutdown to give up after its deadline")
+	}
+
+	if event := <-events; event != "database closed" {
+		t.Errorf("Expected the database to be closed but got %q", event)
+	}
+}
+
+func TestRunServerClosesTheDatabaseWhenServingFails(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener.Close()
+
+	events := make(chan string, 1)
+	err = runServer(context.Background(), &http.Server{}, listener, time.Second, &recordingCloser{events: events})
+
+	if err == nil {
+		t.Error("Expected the serve error to be returned")
+	}
+	if event := <-events; event != "database closed" {
+		t.Errorf("Expected the database to be closed but got %q", event)
+	}
+}
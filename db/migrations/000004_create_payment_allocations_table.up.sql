@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS "payment_allocations" (
+    "allocation_id" SERIAL PRIMARY KEY,
+    "payment_transaction_id" INT NOT NULL,
+    "debt_transaction_id" INT NOT NULL,
+    "amount" NUMERIC(12, 4) NOT NULL,
+    "created_at" timestamp DEFAULT NOW(),
+    CONSTRAINT fk_payment_transaction
+      FOREIGN KEY(payment_transaction_id)
+	  REFERENCES transactions(transaction_id),
+    CONSTRAINT fk_debt_transaction
+      FOREIGN KEY(debt_transaction_id)
+	  REFERENCES transactions(transaction_id)
+);
+
+CREATE INDEX IF NOT EXISTS "idx_payment_allocations_payment" ON "payment_allocations" ("payment_transaction_id");
+CREATE INDEX IF NOT EXISTS "idx_payment_allocations_debt" ON "payment_allocations" ("debt_transaction_id");
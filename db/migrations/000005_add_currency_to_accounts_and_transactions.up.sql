@@ -0,0 +1,2 @@
+ALTER TABLE "accounts" ADD COLUMN IF NOT EXISTS "currency" CHAR(3) NOT NULL DEFAULT 'BRL';
+ALTER TABLE "transactions" ADD COLUMN IF NOT EXISTS "currency" CHAR(3) NOT NULL DEFAULT 'BRL';
@@ -0,0 +1,3 @@
+ALTER TABLE "transactions" ADD COLUMN IF NOT EXISTS "original_amount" NUMERIC(12, 4);
+ALTER TABLE "transactions" ADD COLUMN IF NOT EXISTS "original_currency" CHAR(3);
+ALTER TABLE "transactions" ADD COLUMN IF NOT EXISTS "exchange_rate" NUMERIC(18, 8);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "credit_limit_audits";
+ALTER TABLE "accounts" DROP CONSTRAINT IF EXISTS "chk_available_credit_limit";
+ALTER TABLE "accounts" DROP COLUMN IF EXISTS "available_credit_limit";
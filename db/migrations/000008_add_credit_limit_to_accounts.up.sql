@@ -0,0 +1,23 @@
+-- NULL means no limit is configured, so existing accounts keep spending as
+-- before until a limit is set for them
+ALTER TABLE "accounts" ADD COLUMN IF NOT EXISTS "available_credit_limit" NUMERIC(12, 4);
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'chk_available_credit_limit') THEN
+        ALTER TABLE "accounts" ADD CONSTRAINT "chk_available_credit_limit" CHECK ("available_credit_limit" >= 0);
+    END IF;
+END $$;
+
+CREATE TABLE IF NOT EXISTS "credit_limit_audits" (
+    "audit_id" SERIAL PRIMARY KEY,
+    "account_id" INT NOT NULL,
+    "previous_limit" NUMERIC(12, 4),
+    "new_limit" NUMERIC(12, 4) NOT NULL,
+    "reason" TEXT NOT NULL DEFAULT '',
+    "created_at" timestamp DEFAULT NOW(),
+    CONSTRAINT fk_account
+      FOREIGN KEY(account_id)
+	  REFERENCES accounts(account_id)
+);
+
+CREATE INDEX IF NOT EXISTS "idx_credit_limit_audits_account" ON "credit_limit_audits" ("account_id");
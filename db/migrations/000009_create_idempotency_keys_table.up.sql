@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS "idempotency_keys" (
+    "idempotency_key" VARCHAR(255) NOT NULL,
+    "scope" VARCHAR(255) NOT NULL,
+    "request_hash" CHAR(64) NOT NULL,
+    "status_code" INT,
+    "content_type" TEXT,
+    "response_body" BYTEA,
+    "created_at" timestamp DEFAULT NOW(),
+    PRIMARY KEY ("idempotency_key", "scope")
+);
+
+CREATE INDEX IF NOT EXISTS "idx_idempotency_keys_created" ON "idempotency_keys" ("created_at");
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS "idx_transactions_original_transaction";
+ALTER TABLE "transactions" DROP CONSTRAINT IF EXISTS fk_original_transaction;
+ALTER TABLE "transactions" DROP COLUMN IF EXISTS "original_transaction_id";
+
+DELETE FROM operation_types WHERE operation_type_id IN (5, 6);
@@ -0,0 +1,14 @@
+INSERT INTO operation_types (operation_type_id, description) VALUES (5, 'Reversal') ON CONFLICT (operation_type_id) DO NOTHING;
+INSERT INTO operation_types (operation_type_id, description) VALUES (6, 'Refund') ON CONFLICT (operation_type_id) DO NOTHING;
+
+ALTER TABLE "transactions" ADD COLUMN IF NOT EXISTS "original_transaction_id" INT;
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_original_transaction') THEN
+        ALTER TABLE "transactions" ADD CONSTRAINT fk_original_transaction
+          FOREIGN KEY(original_transaction_id)
+          REFERENCES transactions(transaction_id);
+    END IF;
+END $$;
+
+CREATE INDEX IF NOT EXISTS "idx_transactions_original_transaction" ON "transactions" ("original_transaction_id");
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS "idx_transactions_parent_transaction";
+ALTER TABLE "transactions" DROP CONSTRAINT IF EXISTS fk_parent_transaction;
+ALTER TABLE "transactions" DROP COLUMN IF EXISTS "due_date";
+ALTER TABLE "transactions" DROP COLUMN IF EXISTS "installment_number";
+ALTER TABLE "transactions" DROP COLUMN IF EXISTS "parent_transaction_id";
+ALTER TABLE "transactions" DROP COLUMN IF EXISTS "installment_count";
@@ -0,0 +1,14 @@
+ALTER TABLE "transactions" ADD COLUMN IF NOT EXISTS "installment_count" INT;
+ALTER TABLE "transactions" ADD COLUMN IF NOT EXISTS "parent_transaction_id" INT;
+ALTER TABLE "transactions" ADD COLUMN IF NOT EXISTS "installment_number" INT;
+ALTER TABLE "transactions" ADD COLUMN IF NOT EXISTS "due_date" DATE;
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_parent_transaction') THEN
+        ALTER TABLE "transactions" ADD CONSTRAINT fk_parent_transaction
+          FOREIGN KEY(parent_transaction_id)
+          REFERENCES transactions(transaction_id);
+    END IF;
+END $$;
+
+CREATE INDEX IF NOT EXISTS "idx_transactions_parent_transaction" ON "transactions" ("parent_transaction_id");
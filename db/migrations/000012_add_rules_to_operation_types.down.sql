@@ -0,0 +1,4 @@
+ALTER TABLE "operation_types" DROP CONSTRAINT IF EXISTS "chk_operation_type_sign";
+ALTER TABLE "operation_types" DROP COLUMN IF EXISTS "consumes_credit_limit";
+ALTER TABLE "operation_types" DROP COLUMN IF EXISTS "dischargeable";
+ALTER TABLE "operation_types" DROP COLUMN IF EXISTS "sign";
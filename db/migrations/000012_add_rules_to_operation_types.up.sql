@@ -0,0 +1,12 @@
+ALTER TABLE "operation_types" ADD COLUMN IF NOT EXISTS "sign" VARCHAR(6) NOT NULL DEFAULT 'debit';
+ALTER TABLE "operation_types" ADD COLUMN IF NOT EXISTS "dischargeable" BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE "operation_types" ADD COLUMN IF NOT EXISTS "consumes_credit_limit" BOOLEAN NOT NULL DEFAULT false;
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'chk_operation_type_sign') THEN
+        ALTER TABLE "operation_types" ADD CONSTRAINT "chk_operation_type_sign" CHECK ("sign" IN ('debit', 'credit'));
+    END IF;
+END $$;
+
+UPDATE operation_types SET sign = 'debit', dischargeable = true, consumes_credit_limit = true WHERE operation_type_id IN (1, 2, 3);
+UPDATE operation_types SET sign = 'credit', dischargeable = false, consumes_credit_limit = false WHERE operation_type_id IN (4, 5, 6);
@@ -0,0 +1,2 @@
+ALTER TABLE "accounts" DROP CONSTRAINT IF EXISTS "chk_account_status";
+ALTER TABLE "accounts" DROP COLUMN IF EXISTS "status";
@@ -0,0 +1,7 @@
+ALTER TABLE "accounts" ADD COLUMN IF NOT EXISTS "status" VARCHAR(7) NOT NULL DEFAULT 'active';
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'chk_account_status') THEN
+        ALTER TABLE "accounts" ADD CONSTRAINT "chk_account_status" CHECK ("status" IN ('active', 'blocked', 'closed'));
+    END IF;
+END $$;
@@ -0,0 +1,4 @@
+ALTER TABLE "accounts" DROP CONSTRAINT IF EXISTS "uq_accounts_document_number";
+-- BIGINT rather than the INT of 000001: 11 digit CPFs and 14 digit CNPJs do
+-- not fit in INT. CPF leading zeros are lost, as before this migration.
+ALTER TABLE "accounts" ALTER COLUMN "document_number" TYPE BIGINT USING "document_number"::BIGINT;
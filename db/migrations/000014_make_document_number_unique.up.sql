@@ -0,0 +1,14 @@
+-- the column was INT, so stored CPFs lost their leading zeros; pad them back
+-- to 11 digits so they match the numbers clients send
+ALTER TABLE "accounts" ALTER COLUMN "document_number" TYPE VARCHAR(14) USING LPAD("document_number"::TEXT, 11, '0');
+
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM "accounts" WHERE "document_number" !~ '^([0-9]{11}|[0-9]{14})$') THEN
+        RAISE EXCEPTION 'accounts holds document numbers that are not CPFs or CNPJs';
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'uq_accounts_document_number') THEN
+        ALTER TABLE "accounts" ADD CONSTRAINT "uq_accounts_document_number" UNIQUE ("document_number");
+    END IF;
+END $$;
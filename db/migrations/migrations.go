@@ -0,0 +1,43 @@
+// Package migrations embeds the SQL migrations so the API knows which schema
+// version it was built for.
+package migrations
+
+import (
+	"embed"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+// LatestVersion returns the version of the newest migration, taken from the
+// sequence number golang-migrate puts in front of each file name.
+func LatestVersion() (uint, error) {
+	entries, err := files.ReadDir(".")
+	if err != nil {
+		return 0, err
+	}
+
+	var latest uint
+	for _, entry := range entries {
+		prefix, _, found := strings.Cut(entry.Name(), "_")
+		if !found {
+			return 0, fmt.Errorf("migrations: %s does not start with a version", entry.Name())
+		}
+
+		version, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("migrations: %s does not start with a version", entry.Name())
+		}
+		if uint(version) > latest {
+			latest = uint(version)
+		}
+	}
+
+	if latest == 0 {
+		return 0, fmt.Errorf("migrations: no migration found")
+	}
+	return latest, nil
+}
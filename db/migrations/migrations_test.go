@@ -0,0 +1,30 @@
+package migrations
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestLatestVersionMatchesTheNewestMigration(t *testing.T) {
+	entries, err := os.ReadDir(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var newest string
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), ".up.sql") && entry.Name() > newest {
+			newest = entry.Name()
+		}
+	}
+
+	version, err := LatestVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(newest, fmt.Sprintf("%06d_", version)) {
+		t.Errorf("Expected the version of %s but got %d", newest, version)
+	}
+}
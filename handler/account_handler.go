@@ -1,59 +1,78 @@
 package handler
 
 import (
-	"context"
-	"database/sql"
-	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/aniljaiswalcs/pismo/model"
+	"github.com/aniljaiswalcs/pismo/pkg/document"
 	"github.com/aniljaiswalcs/pismo/pkg/lib"
+	"github.com/aniljaiswalcs/pismo/pkg/money"
 	"github.com/aniljaiswalcs/pismo/repository"
 	"github.com/gorilla/mux"
 )
 
 type AccountHandler struct {
 	repository repository.AccountRepository
+	timeout    time.Duration
 }
 
-func NewAccountHandler(repository repository.AccountRepository) *AccountHandler {
+// NewAccountHandler builds the handler. timeout bounds the repository calls of each
+// request; zero uses DefaultRequestTimeout.
+func NewAccountHandler(repository repository.AccountRepository, timeout time.Duration) *AccountHandler {
 	return &AccountHandler{
 		repository: repository,
+		timeout:    timeout,
 	}
 }
 
 func (c *AccountHandler) CreateAccount(w http.ResponseWriter, req *http.Request) {
 
-	newCtx, cancel := context.WithTimeout(req.Context(), 5*time.Second)
+	newCtx, cancel := requestContext(req, c.timeout)
 	defer cancel()
 
 	payload := &AccountPayload{}
-	err := json.NewDecoder(req.Body).Decode(&payload)
+	if !decodeJSON(w, req, payload) {
+		return
+	}
+
+	documentNumber, err := document.Parse(payload.DocumentNumber)
 	if err != nil {
-		lib.RenderJSON(w, http.StatusBadRequest, err.Error())
+		renderFieldProblem(w, "document_number", lib.CodeInvalidDocumentNumber, lib.DocumentNumberError)
+		return
 	}
 
-	documentNumber := payload.DocumentNumber
-	if documentNumber <= 0 {
-		lib.RenderJSON(w, http.StatusBadRequest, lib.DocumentNumberError)
+	if payload.AvailableCreditLimit != nil && payload.AvailableCreditLimit.IsNegative() {
+		renderFieldProblem(w, "available_credit_limit", lib.CodeInvalidCreditLimit, lib.CreditLimitError)
+		return
+	}
+
+	currency := money.DefaultCurrency
+	if payload.Currency != "" {
+		currency = money.NormalizeCurrency(payload.Currency)
+	}
+	if !money.IsCurrency(currency) {
+		renderFieldProblem(w, "currency", lib.CodeInvalidCurrency, lib.CurrencyError)
 		return
 	}
 
 	account, err := c.repository.CreateAccount(newCtx, model.Account{
-		DocumentNumber: documentNumber,
+		DocumentNumber:       documentNumber,
+		Currency:             currency,
+		AvailableCreditLimit: payload.AvailableCreditLimit,
 	})
 
 	if err != nil {
-		if err.Error() == lib.DatabaseTimeoutError {
-			lib.RenderJSON(w, http.StatusInternalServerError, lib.TimeoutError)
-			return
-		} else if err.Error() == lib.ContextDeadline {
-			lib.RenderJSON(w, http.StatusInternalServerError, lib.TimeoutError)
+		if errors.Is(err, model.ErrDocumentNumberExists) {
+			lib.RenderProblem(w, http.StatusConflict, DuplicateAccountProblem{
+				Problem:   lib.NewProblem(http.StatusConflict, lib.CodeDocumentNumberExists, lib.DocumentNumberExistsError),
+				AccountId: account.AccountId,
+			})
 			return
 		}
-		lib.RenderJSON(w, http.StatusInternalServerError, lib.AccountCreationError)
+		renderError(w, err, lib.CodeNotFound, lib.AccountCreationError, lib.AccountCreationError)
 		return
 	}
 
@@ -63,38 +82,145 @@ func (c *AccountHandler) CreateAccount(w http.ResponseWriter, req *http.Request)
 
 func (c *AccountHandler) GetAccount(w http.ResponseWriter, req *http.Request) {
 
-	newCtx, cancel := context.WithTimeout(req.Context(), 5*time.Second)
+	newCtx, cancel := requestContext(req, c.timeout)
 	defer cancel()
 
 	accountIdParam := mux.Vars(req)["accountId"]
 	accountId, err := strconv.ParseUint(accountIdParam, 10, 64)
 	if err != nil {
-		lib.RenderJSON(w, http.StatusBadRequest, lib.ParsingAccountID)
+		renderProblem(w, http.StatusBadRequest, lib.CodeInvalidAccountId, lib.ParsingAccountID)
 		return
 	}
 	if accountId <= 0 {
-		lib.RenderJSON(w, http.StatusBadRequest, lib.AccountIdValidation)
+		renderProblem(w, http.StatusBadRequest, lib.CodeInvalidAccountId, lib.AccountIdValidation)
 		return
 	}
 
 	account, err := c.repository.FindAccount(newCtx, accountId)
 
 	if err != nil {
-		if err == sql.ErrNoRows {
-			lib.RenderJSON(w, http.StatusNotFound, lib.AccountIdNotFound)
-			return
-		} else if err.Error() == lib.DatabaseTimeoutError || err.Error() == lib.ContextDeadline {
-			lib.RenderJSON(w, http.StatusInternalServerError, lib.TimeoutError)
-			return
-		}
-		lib.RenderJSON(w, http.StatusInternalServerError, lib.DatabaseError)
+		renderError(w, err, lib.CodeAccountNotFound, lib.AccountIdNotFound, lib.DatabaseError)
+		return
+	}
+
+	lib.RenderJSON(w, http.StatusOK, account)
+}
+
+// FindAccountByDocument looks an account up by the CPF or CNPJ given in the
+// document_number query parameter.
+func (c *AccountHandler) FindAccountByDocument(w http.ResponseWriter, req *http.Request) {
+
+	newCtx, cancel := requestContext(req, c.timeout)
+	defer cancel()
+
+	documentNumber, err := document.Parse(req.URL.Query().Get("document_number"))
+	if err != nil {
+		renderFieldProblem(w, "document_number", lib.CodeInvalidDocumentNumber, lib.DocumentNumberError)
+		return
+	}
+
+	account, err := c.repository.FindAccountByDocument(newCtx, documentNumber)
+
+	if err != nil {
+		renderError(w, err, lib.CodeAccountNotFound, lib.DocumentNumberNotFound, lib.DatabaseError)
 		return
 	}
 
 	lib.RenderJSON(w, http.StatusOK, account)
 }
 
+// DuplicateAccountProblem points a client that retried an account creation
+// to the account already opened for the document number.
+type DuplicateAccountProblem struct {
+	lib.Problem
+	AccountId uint64 `json:"account_id"`
+}
+
 type AccountPayload struct {
-	AccountId      uint64 `json:"account_id,omitempty"`
-	DocumentNumber uint64 `json:"document_number"`
+	AccountId uint64 `json:"account_id,omitempty"`
+	// DocumentNumber is a CPF or CNPJ, with or without punctuation.
+	DocumentNumber string `json:"document_number"`
+	Currency       string `json:"currency"`
+	// AvailableCreditLimit is left unset when omitted, and the account may
+	// spend without bound until a limit is set.
+	AvailableCreditLimit *money.Amount `json:"available_credit_limit"`
+}
+
+func (c *AccountHandler) UpdateCreditLimit(w http.ResponseWriter, req *http.Request) {
+
+	newCtx, cancel := requestContext(req, c.timeout)
+	defer cancel()
+
+	accountIdParam := mux.Vars(req)["accountId"]
+	accountId, err := strconv.ParseUint(accountIdParam, 10, 64)
+	if err != nil {
+		renderProblem(w, http.StatusBadRequest, lib.CodeInvalidAccountId, lib.ParsingAccountID)
+		return
+	}
+	if accountId <= 0 {
+		renderProblem(w, http.StatusBadRequest, lib.CodeInvalidAccountId, lib.AccountIdValidation)
+		return
+	}
+
+	payload := &CreditLimitPayload{}
+	if !decodeJSON(w, req, payload) {
+		return
+	}
+	if payload.AvailableCreditLimit == nil || payload.AvailableCreditLimit.IsNegative() {
+		renderFieldProblem(w, "available_credit_limit", lib.CodeInvalidCreditLimit, lib.CreditLimitError)
+		return
+	}
+
+	account, err := c.repository.UpdateCreditLimit(newCtx, accountId, *payload.AvailableCreditLimit, payload.Reason)
+
+	if err != nil {
+		renderError(w, err, lib.CodeAccountNotFound, lib.AccountIdNotFound, lib.CreditLimitUpdateError)
+		return
+	}
+
+	lib.RenderJSON(w, http.StatusOK, account)
+}
+
+type CreditLimitPayload struct {
+	AvailableCreditLimit *money.Amount `json:"available_credit_limit"`
+	Reason               string        `json:"reason"`
+}
+
+func (c *AccountHandler) UpdateStatus(w http.ResponseWriter, req *http.Request) {
+
+	newCtx, cancel := requestContext(req, c.timeout)
+	defer cancel()
+
+	accountIdParam := mux.Vars(req)["accountId"]
+	accountId, err := strconv.ParseUint(accountIdParam, 10, 64)
+	if err != nil {
+		renderProblem(w, http.StatusBadRequest, lib.CodeInvalidAccountId, lib.ParsingAccountID)
+		return
+	}
+	if accountId <= 0 {
+		renderProblem(w, http.StatusBadRequest, lib.CodeInvalidAccountId, lib.AccountIdValidation)
+		return
+	}
+
+	payload := &StatusPayload{}
+	if !decodeJSON(w, req, payload) {
+		return
+	}
+	if !model.ValidateAccountStatus(payload.Status) {
+		renderFieldProblem(w, "status", lib.CodeInvalidAccountStatus, lib.AccountStatusError)
+		return
+	}
+
+	account, err := c.repository.UpdateStatus(newCtx, accountId, payload.Status)
+
+	if err != nil {
+		renderError(w, err, lib.CodeAccountNotFound, lib.AccountIdNotFound, lib.AccountStatusUpdateError)
+		return
+	}
+
+	lib.RenderJSON(w, http.StatusOK, account)
+}
+
+type StatusPayload struct {
+	Status string `json:"status"`
 }
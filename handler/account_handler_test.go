@@ -3,13 +3,11 @@ package handler
 import (
 	"bytes"
 	"context"
-	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
-	"reflect"
 	"testing"
 
 	//"github.com/go-chi/chi"
@@ -19,8 +17,17 @@ import (
 	"github.com/stretchr/testify/mock"
 
 	"github.com/aniljaiswalcs/pismo/model"
+	"github.com/aniljaiswalcs/pismo/pkg/apperror"
+	"github.com/aniljaiswalcs/pismo/pkg/lib"
+	"github.com/aniljaiswalcs/pismo/pkg/money"
 )
 
+// amount returns a pointer to the parsed amount, for optional amounts.
+func amount(value string) *money.Amount {
+	parsed := money.MustParse(value)
+	return &parsed
+}
+
 type MockAccountRepository struct {
 	mock.Mock
 }
@@ -35,6 +42,21 @@ func (m *MockAccountRepository) FindAccount(ctx context.Context, accountId uint6
 	return args.Get(0).(*model.Account), args.Error(1)
 }
 
+func (m *MockAccountRepository) FindAccountByDocument(ctx context.Context, documentNumber string) (*model.Account, error) {
+	args := m.Called(ctx, documentNumber)
+	return args.Get(0).(*model.Account), args.Error(1)
+}
+
+func (m *MockAccountRepository) UpdateCreditLimit(ctx context.Context, accountId uint64, limit money.Amount, reason string) (*model.Account, error) {
+	args := m.Called(ctx, accountId, limit, reason)
+	return args.Get(0).(*model.Account), args.Error(1)
+}
+
+func (m *MockAccountRepository) UpdateStatus(ctx context.Context, accountId uint64, status string) (*model.Account, error) {
+	args := m.Called(ctx, accountId, status)
+	return args.Get(0).(*model.Account), args.Error(1)
+}
+
 func TestGetAccount(t *testing.T) {
 	mockRepo := new(MockAccountRepository)
 	handler := &AccountHandler{repository: mockRepo}
@@ -48,7 +70,7 @@ func TestGetAccount(t *testing.T) {
 	rr := httptest.NewRecorder()
 
 	expectedAccountID := uint64(123)
-	expectedDocumentNumber := uint64(44)
+	expectedDocumentNumber := "52998224725"
 	expectedAccount := &model.Account{
 		AccountId:      expectedAccountID,
 		DocumentNumber: expectedDocumentNumber,
@@ -85,7 +107,7 @@ func TestGetAccountFailsWhenInvalidRequest(t *testing.T) {
 		{
 			"Row not found",
 			99,
-			sql.ErrNoRows,
+			apperror.ErrNotFound,
 			http.StatusNotFound,
 		},
 		{
@@ -122,10 +144,10 @@ func TestGetAccountFailsWhenInvalidRequest(t *testing.T) {
 
 func TestCreateAccount(t *testing.T) {
 	mockRepo := new(MockAccountRepository)
-	handler := NewAccountHandler(mockRepo)
+	handler := NewAccountHandler(mockRepo, 0)
 
 	payload := &AccountPayload{
-		DocumentNumber: 123456,
+		DocumentNumber: "529.982.247-25",
 	}
 	requestBody, _ := json.Marshal(payload)
 
@@ -138,7 +160,8 @@ func TestCreateAccount(t *testing.T) {
 	rr := httptest.NewRecorder()
 	expectedAccount := &model.Account{
 		AccountId:      payload.AccountId,
-		DocumentNumber: payload.DocumentNumber,
+		DocumentNumber: "52998224725",
+		Currency:       "BRL",
 	}
 	mockRepo.On("CreateAccount", mock.Anything, *expectedAccount).Return(expectedAccount, nil)
 
@@ -151,6 +174,7 @@ func TestCreateAccount(t *testing.T) {
 	}
 	assert.Equal(t, expectedAccount.AccountId, responseAccount.AccountId)
 	assert.Equal(t, expectedAccount.DocumentNumber, responseAccount.DocumentNumber)
+	assert.Equal(t, expectedAccount.Currency, responseAccount.Currency)
 }
 
 func TestCreateAccountFailsWhenInvalidRequest(t *testing.T) {
@@ -165,7 +189,27 @@ func TestCreateAccountFailsWhenInvalidRequest(t *testing.T) {
 			Name:             "Invalid JSON Payload",
 			Payload:          &AccountPayload{},
 			ExpectedCode:     http.StatusBadRequest,
-			ExpectedResponse: "the document_number must be a valid positive integer",
+			ExpectedResponse: validationProblemBody(lib.FieldError{Field: "document_number", Code: lib.CodeInvalidDocumentNumber, Detail: lib.DocumentNumberError}),
+			ExpectedReturn:   &model.Account{},
+		},
+		{
+			Name: "Invalid currency",
+			Payload: &AccountPayload{
+				DocumentNumber: "52998224725",
+				Currency:       "XYZ",
+			},
+			ExpectedCode:     http.StatusBadRequest,
+			ExpectedResponse: validationProblemBody(lib.FieldError{Field: "currency", Code: lib.CodeInvalidCurrency, Detail: lib.CurrencyError}),
+			ExpectedReturn:   &model.Account{},
+		},
+		{
+			Name: "Negative credit limit",
+			Payload: &AccountPayload{
+				DocumentNumber:       "52998224725",
+				AvailableCreditLimit: amount("-1"),
+			},
+			ExpectedCode:     http.StatusBadRequest,
+			ExpectedResponse: validationProblemBody(lib.FieldError{Field: "available_credit_limit", Code: lib.CodeInvalidCreditLimit, Detail: lib.CreditLimitError}),
 			ExpectedReturn:   &model.Account{},
 		},
 		{
@@ -174,12 +218,12 @@ func TestCreateAccountFailsWhenInvalidRequest(t *testing.T) {
 				AccountId: 222,
 			},
 			ExpectedCode:     http.StatusBadRequest,
-			ExpectedResponse: "the document_number must be a valid positive integer",
+			ExpectedResponse: validationProblemBody(lib.FieldError{Field: "document_number", Code: lib.CodeInvalidDocumentNumber, Detail: lib.DocumentNumberError}),
 			ExpectedReturn:   &model.Account{},
 		},
 	}
 	mockRepo := new(MockAccountRepository)
-	handler := NewAccountHandler(mockRepo)
+	handler := NewAccountHandler(mockRepo, 0)
 	for _, tc := range testCases {
 		t.Run(tc.Name, func(t *testing.T) {
 
@@ -198,15 +242,247 @@ func TestCreateAccountFailsWhenInvalidRequest(t *testing.T) {
 			handler.CreateAccount(recorder, req)
 
 			assert.Equal(t, tc.ExpectedCode, recorder.Code)
-			expectedResponseJson := map[string]string{}
-			actualResponseJson := map[string]string{}
+			assert.Equal(t, lib.ProblemContentType, recorder.Header().Get("Content-Type"))
+			assert.JSONEq(t, tc.ExpectedResponse, recorder.Body.String())
+		})
+	}
+}
+
+func TestCreateAccountStopsWhenBodyIsRejected(t *testing.T) {
+	mockRepo := new(MockAccountRepository)
+	handler := NewAccountHandler(mockRepo, 0)
 
-			json.Unmarshal([]byte(tc.ExpectedResponse), &expectedResponseJson)
-			json.Unmarshal([]byte(recorder.Body.Bytes()), &actualResponseJson)
+	req, _ := http.NewRequest("POST", "/v1/accounts", bytes.NewReader([]byte(`{"document_number": 52998224725}`)))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	handler.CreateAccount(rr, req)
 
-			if !reflect.DeepEqual(expectedResponseJson, actualResponseJson) {
-				t.Errorf("Expected response body %s but got %s", tc.ExpectedResponse, recorder.Body.String())
-			}
-		})
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.JSONEq(t, validationProblemBody(lib.FieldError{Field: "document_number", Code: lib.CodeInvalidType, Detail: lib.InvalidTypeError}), rr.Body.String())
+	mockRepo.AssertNotCalled(t, "CreateAccount")
+}
+
+func TestCreateAccountWithExistingDocumentNumber(t *testing.T) {
+	var scenarios = []struct {
+		description        string
+		documentNumber     string
+		expectedStatusCode int
+		expectedResponse   string
+	}{
+		{
+			"Duplicate CNPJ returns the existing account",
+			"11.222.333/0001-81",
+			http.StatusConflict,
+			`{"type":"/problems/document_number_exists","title":"Conflict","status":409,"detail":"` + lib.DocumentNumberExistsError + `","code":"document_number_exists","account_id":7}`,
+		},
+		{
+			"Invalid check digits",
+			"11.222.333/0001-80",
+			http.StatusBadRequest,
+			validationProblemBody(lib.FieldError{Field: "document_number", Code: lib.CodeInvalidDocumentNumber, Detail: lib.DocumentNumberError}),
+		},
+	}
+
+	for _, scenario := range scenarios {
+		mockRepo := new(MockAccountRepository)
+		handler := NewAccountHandler(mockRepo, 0)
+
+		existingAccount := &model.Account{AccountId: 7, DocumentNumber: "11222333000181", Currency: "BRL", Status: model.ACCOUNT_ACTIVE}
+		mockRepo.On("CreateAccount", mock.Anything, model.Account{DocumentNumber: "11222333000181", Currency: "BRL"}).Return(existingAccount, model.ErrDocumentNumberExists)
+
+		requestBody, _ := json.Marshal(AccountPayload{DocumentNumber: scenario.documentNumber})
+		req, _ := http.NewRequest("POST", "/v1/accounts", bytes.NewReader(requestBody))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		handler.CreateAccount(rr, req)
+
+		assert.Equal(t, scenario.expectedStatusCode, rr.Code, scenario.description)
+		assert.JSONEq(t, scenario.expectedResponse, rr.Body.String(), scenario.description)
+	}
+}
+
+func TestUpdateCreditLimit(t *testing.T) {
+	var scenarios = []struct {
+		description        string
+		payload            string
+		repositoryError    error
+		expectedStatusCode int
+	}{
+		{
+			"Limit updated",
+			`{"available_credit_limit": 1500.5, "reason": "customer request"}`,
+			nil,
+			http.StatusOK,
+		},
+		{
+			"Account not found",
+			`{"available_credit_limit": 1500.5, "reason": "customer request"}`,
+			apperror.ErrNotFound,
+			http.StatusNotFound,
+		},
+		{
+			"Missing limit",
+			`{"reason": "customer request"}`,
+			nil,
+			http.StatusBadRequest,
+		},
+		{
+			"Negative limit",
+			`{"available_credit_limit": -10}`,
+			nil,
+			http.StatusBadRequest,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		mockRepo := new(MockAccountRepository)
+		handler := NewAccountHandler(mockRepo, 0)
+
+		expectedAccount := &model.Account{AccountId: 5, DocumentNumber: "52998224725", Currency: "BRL", AvailableCreditLimit: amount("1500.5")}
+		mockRepo.On("UpdateCreditLimit", mock.Anything, uint64(5), money.MustParse("1500.5"), "customer request").Return(expectedAccount, scenario.repositoryError)
+
+		router := mux.NewRouter()
+		router.HandleFunc("/v1/accounts/{accountId:[0-9]+}/credit-limit", handler.UpdateCreditLimit).Methods("PATCH")
+
+		req, _ := http.NewRequest("PATCH", "/v1/accounts/5/credit-limit", bytes.NewReader([]byte(scenario.payload)))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, scenario.expectedStatusCode, rr.Code, scenario.description)
+		if scenario.expectedStatusCode == http.StatusOK {
+			var responseAccount model.Account
+			json.Unmarshal(rr.Body.Bytes(), &responseAccount)
+			assert.Equal(t, *expectedAccount, responseAccount, scenario.description)
+		}
+	}
+}
+
+func TestUpdateStatus(t *testing.T) {
+	var scenarios = []struct {
+		description        string
+		payload            string
+		repositoryError    error
+		expectedStatusCode int
+		expectedResponse   string
+	}{
+		{
+			"Account blocked",
+			`{"status": "blocked"}`,
+			nil,
+			http.StatusOK,
+			`{"account_id":5,"document_number":"52998224725","currency":"BRL","status":"blocked","available_credit_limit":null}`,
+		},
+		{
+			"Unknown status",
+			`{"status": "frozen"}`,
+			nil,
+			http.StatusBadRequest,
+			validationProblemBody(lib.FieldError{Field: "status", Code: lib.CodeInvalidAccountStatus, Detail: lib.AccountStatusError}),
+		},
+		{
+			"Account not found",
+			`{"status": "blocked"}`,
+			apperror.ErrNotFound,
+			http.StatusNotFound,
+			problemBody(http.StatusNotFound, lib.CodeAccountNotFound, lib.AccountIdNotFound),
+		},
+		{
+			"Closed accounts stay closed",
+			`{"status": "blocked"}`,
+			model.ErrInvalidStatusTransition,
+			http.StatusUnprocessableEntity,
+			problemBody(http.StatusUnprocessableEntity, lib.CodeInvalidAccountStatusChange, lib.AccountStatusTransitionError),
+		},
+	}
+
+	for _, scenario := range scenarios {
+		mockRepo := new(MockAccountRepository)
+		handler := NewAccountHandler(mockRepo, 0)
+
+		expectedAccount := &model.Account{AccountId: 5, DocumentNumber: "52998224725", Currency: "BRL", Status: model.ACCOUNT_BLOCKED}
+		mockRepo.On("UpdateStatus", mock.Anything, uint64(5), model.ACCOUNT_BLOCKED).Return(expectedAccount, scenario.repositoryError)
+
+		router := mux.NewRouter()
+		router.HandleFunc("/v1/accounts/{accountId:[0-9]+}/status", handler.UpdateStatus).Methods("PATCH")
+
+		req, _ := http.NewRequest("PATCH", "/v1/accounts/5/status", bytes.NewReader([]byte(scenario.payload)))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, scenario.expectedStatusCode, rr.Code, scenario.description)
+		assert.JSONEq(t, scenario.expectedResponse, rr.Body.String(), scenario.description)
+	}
+}
+
+func TestCloseAccountWithOutstandingDebt(t *testing.T) {
+	mockRepo := new(MockAccountRepository)
+	handler := NewAccountHandler(mockRepo, 0)
+	mockRepo.On("UpdateStatus", mock.Anything, uint64(5), model.ACCOUNT_CLOSED).Return(&model.Account{}, model.ErrOutstandingDebt)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/v1/accounts/{accountId:[0-9]+}/status", handler.UpdateStatus).Methods("PATCH")
+
+	req, _ := http.NewRequest("PATCH", "/v1/accounts/5/status", bytes.NewReader([]byte(`{"status": "closed"}`)))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	assert.JSONEq(t, problemBody(http.StatusUnprocessableEntity, lib.CodeOutstandingDebt, lib.OutstandingDebtError), rr.Body.String())
+}
+
+func TestFindAccountByDocument(t *testing.T) {
+	var scenarios = []struct {
+		description        string
+		query              string
+		repositoryError    error
+		expectedStatusCode int
+		expectedResponse   string
+	}{
+		{
+			"Account found by formatted CPF",
+			"?document_number=529.982.247-25",
+			nil,
+			http.StatusOK,
+			`{"account_id":5,"document_number":"52998224725","currency":"BRL","status":"active","available_credit_limit":null}`,
+		},
+		{
+			"No account for the document",
+			"?document_number=52998224725",
+			apperror.ErrNotFound,
+			http.StatusNotFound,
+			problemBody(http.StatusNotFound, lib.CodeAccountNotFound, lib.DocumentNumberNotFound),
+		},
+		{
+			"Missing document number",
+			"",
+			nil,
+			http.StatusBadRequest,
+			validationProblemBody(lib.FieldError{Field: "document_number", Code: lib.CodeInvalidDocumentNumber, Detail: lib.DocumentNumberError}),
+		},
+		{
+			"Invalid document number",
+			"?document_number=52998224724",
+			nil,
+			http.StatusBadRequest,
+			validationProblemBody(lib.FieldError{Field: "document_number", Code: lib.CodeInvalidDocumentNumber, Detail: lib.DocumentNumberError}),
+		},
+	}
+
+	for _, scenario := range scenarios {
+		mockRepo := new(MockAccountRepository)
+		handler := NewAccountHandler(mockRepo, 0)
+
+		expectedAccount := &model.Account{AccountId: 5, DocumentNumber: "52998224725", Currency: "BRL", Status: model.ACCOUNT_ACTIVE}
+		mockRepo.On("FindAccountByDocument", mock.Anything, "52998224725").Return(expectedAccount, scenario.repositoryError)
+
+		req, _ := http.NewRequest("GET", "/v1/accounts"+scenario.query, nil)
+		rr := httptest.NewRecorder()
+		handler.FindAccountByDocument(rr, req)
+
+		assert.Equal(t, scenario.expectedStatusCode, rr.Code, scenario.description)
+		assert.JSONEq(t, scenario.expectedResponse, rr.Body.String(), scenario.description)
 	}
 }
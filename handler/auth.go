@@ -0,0 +1,26 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/aniljaiswalcs/pismo/pkg/lib"
+)
+
+// RequireBearerToken lets through only the requests whose Authorization
+// header carries token as a bearer token, and answers 401 to the others.
+func RequireBearerToken(token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			presented, found := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+			if !found || subtle.ConstantTimeCompare([]byte(presented), []byte(token)) != 1 {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				renderProblem(w, http.StatusUnauthorized, lib.CodeUnauthorized, lib.UnauthorizedError)
+				return
+			}
+
+			next.ServeHTTP(w, req)
+		})
+	}
+}
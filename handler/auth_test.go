@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/aniljaiswalcs/pismo/pkg/lib"
+)
+
+func TestRequireBearerToken(t *testing.T) {
+	const token = "0123456789abcdef0123456789abcdef"
+
+	var scenarios = []struct {
+		description        string
+		authorization      string
+		expectedStatusCode int
+		expectedNextCalls  int
+	}{
+		{"Valid token", "Bearer " + token, http.StatusOK, 1},
+		{"Missing header", "", http.StatusUnauthorized, 0},
+		{"Wrong token", "Bearer " + token[1:] + "0", http.StatusUnauthorized, 0},
+		{"Other scheme", "Basic " + token, http.StatusUnauthorized, 0},
+	}
+
+	for _, scenario := range scenarios {
+		nextCalls := 0
+		next := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			nextCalls++
+		})
+
+		req, _ := http.NewRequest("POST", "/v1/admin/operation-types", nil)
+		if scenario.authorization != "" {
+			req.Header.Set("Authorization", scenario.authorization)
+		}
+		w := httptest.NewRecorder()
+		RequireBearerToken(token)(next).ServeHTTP(w, req)
+
+		assert.Equal(t, scenario.expectedStatusCode, w.Code, scenario.description)
+		assert.Equal(t, scenario.expectedNextCalls, nextCalls, scenario.description)
+		if scenario.expectedStatusCode == http.StatusUnauthorized {
+			assert.Equal(t, "Bearer", w.Header().Get("WWW-Authenticate"), scenario.description)
+			assert.JSONEq(t, problemBody(http.StatusUnauthorized, lib.CodeUnauthorized, lib.UnauthorizedError), w.Body.String(), scenario.description)
+		}
+	}
+}
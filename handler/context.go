@@ -0,0 +1,19 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// DefaultRequestTimeout bounds the repository calls of a request when the
+// handler was built without a timeout.
+const DefaultRequestTimeout = 5 * time.Second
+
+// requestContext derives the context a handler hands to its repository.
+func requestContext(req *http.Request, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		timeout = DefaultRequestTimeout
+	}
+	return context.WithTimeout(req.Context(), timeout)
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/aniljaiswalcs/pismo/pkg/lib"
+)
+
+const maxRequestBodyBytes = 1 << 20
+
+// bodyError explains why a request body was rejected. Field is set when the
+// problem lies with a single field of the object.
+type bodyError struct {
+	status int
+	code   string
+	detail string
+	field  string
+}
+
+func (e *bodyError) Error() string {
+	return e.detail
+}
+
+// decodeJSON reads the request body into dst, which must point to a struct.
+// The body must be a single JSON object of at most maxRequestBodyBytes sent
+// as application/json, and may only carry fields dst knows about. When the
+// body is rejected decodeJSON answers the request and returns false.
+func decodeJSON(w http.ResponseWriter, req *http.Request, dst interface{}) bool {
+	err := readJSON(w, req, dst)
+	if err == nil {
+		return true
+	}
+
+	if err.field != "" {
+		renderFieldProblem(w, err.field, err.code, err.detail)
+		return false
+	}
+	renderProblem(w, err.status, err.code, err.detail)
+	return false
+}
+
+func readJSON(w http.ResponseWriter, req *http.Request, dst interface{}) *bodyError {
+	mediaType, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil || mediaType != "application/json" {
+		return &bodyError{status: http.StatusUnsupportedMediaType, code: lib.CodeUnsupportedMediaType, detail: lib.UnsupportedMediaTypeError}
+	}
+
+	decoder := json.NewDecoder(http.MaxBytesReader(w, req.Body, maxRequestBodyBytes))
+
+	var object json.RawMessage
+	if err = decoder.Decode(&object); err != nil {
+		return malformedBody(err)
+	}
+	if err = decoder.Decode(&struct{}{}); err != io.EOF {
+		return malformedBody(err)
+	}
+	if !bytes.HasPrefix(object, []byte("{")) {
+		return malformedBody(nil)
+	}
+
+	strict := json.NewDecoder(bytes.NewReader(object))
+	strict.DisallowUnknownFields()
+	err = strict.Decode(dst)
+	if err == nil {
+		return nil
+	}
+
+	var typeError *json.UnmarshalTypeError
+	if errors.As(err, &typeError) && typeError.Field != "" {
+		return &bodyError{status: http.StatusBadRequest, code: lib.CodeInvalidType, detail: lib.InvalidTypeError, field: typeError.Field}
+	}
+	if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		return &bodyError{status: http.StatusBadRequest, code: lib.CodeUnknownField, detail: lib.UnknownFieldError, field: strings.Trim(field, `"`)}
+	}
+
+	return &bodyError{status: http.StatusBadRequest, code: lib.CodeMalformedBody, detail: err.Error()}
+}
+
+func malformedBody(err error) *bodyError {
+	var maxBytesError *http.MaxBytesError
+	if errors.As(err, &maxBytesError) {
+		return &bodyError{status: http.StatusRequestEntityTooLarge, code: lib.CodeBodyTooLarge, detail: lib.BodyTooLargeError}
+	}
+
+	return &bodyError{status: http.StatusBadRequest, code: lib.CodeMalformedBody, detail: lib.MalformedBodyError}
+}
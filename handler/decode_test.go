@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/aniljaiswalcs/pismo/pkg/lib"
+)
+
+func TestDecodeJSON(t *testing.T) {
+	var scenarios = []struct {
+		description        string
+		contentType        string
+		body               string
+		expectedOk         bool
+		expectedStatusCode int
+		expectedResponse   string
+	}{
+		{
+			"Valid object",
+			"application/json",
+			`{"status": "blocked"}`,
+			true,
+			http.StatusOK,
+			"",
+		},
+		{
+			"Content type with charset",
+			"application/json; charset=utf-8",
+			`{"status": "blocked"}`,
+			true,
+			http.StatusOK,
+			"",
+		},
+		{
+			"Missing content type",
+			"",
+			`{"status": "blocked"}`,
+			false,
+			http.StatusUnsupportedMediaType,
+			problemBody(http.StatusUnsupportedMediaType, lib.CodeUnsupportedMediaType, lib.UnsupportedMediaTypeError),
+		},
+		{
+			"Form content type",
+			"application/x-www-form-urlencoded",
+			`status=blocked`,
+			false,
+			http.StatusUnsupportedMediaType,
+			problemBody(http.StatusUnsupportedMediaType, lib.CodeUnsupportedMediaType, lib.UnsupportedMediaTypeError),
+		},
+		{
+			"Body too large",
+			"application/json",
+			`{"status": "` + strings.Repeat("a", maxRequestBodyBytes) + `"}`,
+			false,
+			http.StatusRequestEntityTooLarge,
+			problemBody(http.StatusRequestEntityTooLarge, lib.CodeBodyTooLarge, lib.BodyTooLargeError),
+		},
+		{
+			"Empty body",
+			"application/json",
+			``,
+			false,
+			http.StatusBadRequest,
+			problemBody(http.StatusBadRequest, lib.CodeMalformedBody, lib.MalformedBodyError),
+		},
+		{
+			"Invalid JSON",
+			"application/json",
+			`{"status": `,
+			false,
+			http.StatusBadRequest,
+			problemBody(http.StatusBadRequest, lib.CodeMalformedBody, lib.MalformedBodyError),
+		},
+		{
+			"Two objects",
+			"application/json",
+			`{"status": "blocked"}{"status": "closed"}`,
+			false,
+			http.StatusBadRequest,
+			problemBody(http.StatusBadRequest, lib.CodeMalformedBody, lib.MalformedBodyError),
+		},
+		{
+			"Array instead of an object",
+			"application/json",
+			`[{"status": "blocked"}]`,
+			false,
+			http.StatusBadRequest,
+			problemBody(http.StatusBadRequest, lib.CodeMalformedBody, lib.MalformedBodyError),
+		},
+		{
+			"Null instead of an object",
+			"application/json",
+			`null`,
+			false,
+			http.StatusBadRequest,
+			problemBody(http.StatusBadRequest, lib.CodeMalformedBody, lib.MalformedBodyError),
+		},
+		{
+			"Unknown field",
+			"application/json",
+			`{"status": "blocked", "reason": "fraud"}`,
+			false,
+			http.StatusBadRequest,
+			validationProblemBody(lib.FieldError{Field: "reason", Code: lib.CodeUnknownField, Detail: lib.UnknownFieldError}),
+		},
+		{
+			"Wrong type",
+			"application/json",
+			`{"status": 1}`,
+			false,
+			http.StatusBadRequest,
+			validationProblemBody(lib.FieldError{Field: "status", Code: lib.CodeInvalidType, Detail: lib.InvalidTypeError}),
+		},
+	}
+
+	for _, scenario := range scenarios {
+		req, _ := http.NewRequest("PATCH", "/v1/accounts/5/status", strings.NewReader(scenario.body))
+		if scenario.contentType != "" {
+			req.Header.Set("Content-Type", scenario.contentType)
+		}
+		w := httptest.NewRecorder()
+
+		payload := &StatusPayload{}
+		ok := decodeJSON(w, req, payload)
+
+		assert.Equal(t, scenario.expectedOk, ok, scenario.description)
+		assert.Equal(t, scenario.expectedStatusCode, w.Code, scenario.description)
+		if scenario.expectedOk {
+			assert.Equal(t, "blocked", payload.Status, scenario.description)
+			continue
+		}
+		assert.JSONEq(t, scenario.expectedResponse, w.Body.String(), scenario.description)
+	}
+}
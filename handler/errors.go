@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/aniljaiswalcs/pismo/model"
+	"github.com/aniljaiswalcs/pismo/pkg/apperror"
+	"github.com/aniljaiswalcs/pismo/pkg/lib"
+)
+
+// domainErrorMessages holds the code and message sent back for each domain
+// error; the status comes from the error kind like for any other error.
+var domainErrorMessages = []struct {
+	err     error
+	code    string
+	message string
+}{
+	{model.ErrCurrencyMismatch, lib.CodeCurrencyMismatch, lib.CurrencyMismatchError},
+	{model.ErrExchangeRateUnavailable, lib.CodeExchangeRateUnavailable, lib.ExchangeRateError},
+	{model.ErrConvertedAmountOutOfRange, lib.CodeInvalidAmount, lib.ConvertedAmountError},
+	{model.ErrInsufficientCreditLimit, lib.CodeInsufficientCreditLimit, lib.InsufficientCreditLimitError},
+	{model.ErrDocumentNumberExists, lib.CodeDocumentNumberExists, lib.DocumentNumberExistsError},
+	{model.ErrAccountBlocked, lib.CodeAccountBlocked, lib.AccountBlockedError},
+	{model.ErrAccountClosed, lib.CodeAccountClosed, lib.AccountClosedError},
+	{model.ErrInvalidStatusTransition, lib.CodeInvalidAccountStatusChange, lib.AccountStatusTransitionError},
+	{model.ErrOutstandingDebt, lib.CodeOutstandingDebt, lib.OutstandingDebtError},
+	{model.ErrInvalidOperationType, lib.CodeInvalidOperationType, lib.OperationTypeDefinitionError},
+	{model.ErrOperationTypeExists, lib.CodeOperationTypeExists, lib.OperationTypeExistsError},
+	{model.ErrOriginalTransactionNotFound, lib.CodeOriginalTransactionNotFound, lib.OriginalTransactionNotFound},
+	{model.ErrOriginalTransactionNotRefundable, lib.CodeOriginalTransactionNotRefundable, lib.OriginalTransactionNotRefundable},
+	{model.ErrRefundExceedsOriginal, lib.CodeRefundExceedsOriginal, lib.RefundExceedsOriginalError},
+	{model.ErrReversalNotFull, lib.CodeReversalNotFull, lib.ReversalNotFullError},
+}
+
+// errorStatus maps the kind of an error to its HTTP status code. Errors
+// without a kind are unexpected and answered with 500.
+func errorStatus(err error) int {
+	switch apperror.Kind(err) {
+	case apperror.ErrNotFound:
+		return http.StatusNotFound
+	case apperror.ErrConflict:
+		return http.StatusConflict
+	case apperror.ErrValidation:
+		return http.StatusUnprocessableEntity
+	case apperror.ErrTimeout:
+		return http.StatusGatewayTimeout
+	case apperror.ErrUnavailable:
+		return http.StatusServiceUnavailable
+	}
+
+	return http.StatusInternalServerError
+}
+
+// renderError answers a failed repository call. Domain errors are sent with
+// their own code and message, missing resources with notFoundCode and
+// notFound and unexpected errors with fallback.
+func renderError(w http.ResponseWriter, err error, notFoundCode string, notFound string, fallback string) {
+	status := errorStatus(err)
+
+	for _, domainError := range domainErrorMessages {
+		if errors.Is(err, domainError.err) {
+			renderProblem(w, status, domainError.code, domainError.message)
+			return
+		}
+	}
+
+	switch status {
+	case http.StatusNotFound:
+		renderProblem(w, status, notFoundCode, notFound)
+	case http.StatusConflict:
+		renderProblem(w, status, lib.CodeConflict, lib.ConflictError)
+	case http.StatusUnprocessableEntity:
+		renderProblem(w, status, lib.CodeConstraintViolation, lib.ConstraintError)
+	case http.StatusGatewayTimeout:
+		renderProblem(w, status, lib.CodeTimeout, lib.TimeoutError)
+	case http.StatusServiceUnavailable:
+		renderProblem(w, status, lib.CodeUnavailable, lib.UnavailableError)
+	default:
+		renderProblem(w, status, lib.CodeInternalError, fallback)
+	}
+}
+
+func renderProblem(w http.ResponseWriter, status int, code string, detail string) {
+	lib.RenderProblem(w, status, lib.NewProblem(status, code, detail))
+}
+
+// renderValidationProblem answers 400 with one entry per invalid field.
+func renderValidationProblem(w http.ResponseWriter, fieldErrors []lib.FieldError) {
+	problem := lib.NewProblem(http.StatusBadRequest, lib.CodeValidationFailed, lib.ValidationError)
+	problem.Errors = fieldErrors
+	lib.RenderProblem(w, http.StatusBadRequest, problem)
+}
+
+// renderFieldProblem answers 400 for a request with a single invalid field.
+func renderFieldProblem(w http.ResponseWriter, field string, code string, detail string) {
+	renderValidationProblem(w, []lib.FieldError{{Field: field, Code: code, Detail: detail}})
+}
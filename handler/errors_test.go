@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/aniljaiswalcs/pismo/model"
+	"github.com/aniljaiswalcs/pismo/pkg/apperror"
+	"github.com/aniljaiswalcs/pismo/pkg/lib"
+)
+
+// problemBody is the problem details body expected for a plain error.
+func problemBody(status int, code string, detail string) string {
+	body, _ := json.Marshal(lib.NewProblem(status, code, detail))
+	return string(body)
+}
+
+// validationProblemBody is the problem details body expected for invalid
+// request fields.
+func validationProblemBody(fieldErrors ...lib.FieldError) string {
+	problem := lib.NewProblem(http.StatusBadRequest, lib.CodeValidationFailed, lib.ValidationError)
+	problem.Errors = fieldErrors
+	body, _ := json.Marshal(problem)
+	return string(body)
+}
+
+func TestRenderError(t *testing.T) {
+	var scenarios = []struct {
+		name            string
+		err             error
+		expectedStatus  int
+		expectedCode    string
+		expectedMessage string
+	}{
+		{"Not found", apperror.Wrap(apperror.ErrNotFound, errors.New("sql: no rows in result set")), http.StatusNotFound, lib.CodeAccountNotFound, lib.AccountIdNotFound},
+		{"Conflict", apperror.New(apperror.ErrConflict, "duplicate key"), http.StatusConflict, lib.CodeConflict, lib.ConflictError},
+		{"Constraint violation", apperror.New(apperror.ErrValidation, "foreign key violation"), http.StatusUnprocessableEntity, lib.CodeConstraintViolation, lib.ConstraintError},
+		{"Timeout", apperror.Wrap(apperror.ErrTimeout, context.DeadlineExceeded), http.StatusGatewayTimeout, lib.CodeTimeout, lib.TimeoutError},
+		{"Unavailable", apperror.New(apperror.ErrUnavailable, "connection refused"), http.StatusServiceUnavailable, lib.CodeUnavailable, lib.UnavailableError},
+		{"Domain error", model.ErrInsufficientCreditLimit, http.StatusUnprocessableEntity, lib.CodeInsufficientCreditLimit, lib.InsufficientCreditLimitError},
+		{"Converted amount out of range", model.ErrConvertedAmountOutOfRange, http.StatusUnprocessableEntity, lib.CodeInvalidAmount, lib.ConvertedAmountError},
+		{"Domain conflict", model.ErrDocumentNumberExists, http.StatusConflict, lib.CodeDocumentNumberExists, lib.DocumentNumberExistsError},
+		{"Unclassified", errors.New("Error!"), http.StatusInternalServerError, lib.CodeInternalError, lib.TransactionCreationError},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+
+			renderError(w, scenario.err, lib.CodeAccountNotFound, lib.AccountIdNotFound, lib.TransactionCreationError)
+
+			assert.Equal(t, scenario.expectedStatus, w.Code)
+			assert.Equal(t, lib.ProblemContentType, w.Header().Get("Content-Type"))
+			assert.JSONEq(t, problemBody(scenario.expectedStatus, scenario.expectedCode, scenario.expectedMessage), w.Body.String())
+		})
+	}
+}
+
+func TestRenderValidationProblem(t *testing.T) {
+	w := httptest.NewRecorder()
+
+	renderValidationProblem(w, []lib.FieldError{{Field: "currency", Code: lib.CodeInvalidCurrency, Detail: lib.CurrencyError}})
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, lib.ProblemContentType, w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{
+		"type": "/problems/validation_failed",
+		"title": "Bad Request",
+		"status": 400,
+		"detail": "`+lib.ValidationError+`",
+		"code": "validation_failed",
+		"errors": [{"field": "currency", "code": "invalid_currency", "detail": "`+lib.CurrencyError+`"}]
+	}`, w.Body.String())
+}
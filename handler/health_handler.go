@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/aniljaiswalcs/pismo/pkg/lib"
+	"github.com/aniljaiswalcs/pismo/repository"
+)
+
+const (
+	HealthOK          = "ok"
+	HealthFailing     = "failing"
+	HealthUnavailable = "unavailable"
+)
+
+type HealthHandler struct {
+	repository      repository.HealthRepository
+	expectedVersion uint
+	timeout         time.Duration
+}
+
+// NewHealthHandler builds the handler. expectedVersion is the migration the
+// database schema must be at for the API to be ready; timeout bounds the
+// checks of each probe, zero uses DefaultRequestTimeout.
+func NewHealthHandler(repository repository.HealthRepository, expectedVersion uint, timeout time.Duration) *HealthHandler {
+	return &HealthHandler{
+		repository:      repository,
+		expectedVersion: expectedVersion,
+		timeout:         timeout,
+	}
+}
+
+type LivenessResponse struct {
+	Status string `json:"status"`
+}
+
+// ReadinessResponse reports every dependency the API needs to serve requests.
+// Status is ok only when every check is.
+type ReadinessResponse struct {
+	Status string          `json:"status"`
+	Checks ReadinessChecks `json:"checks"`
+}
+
+type ReadinessChecks struct {
+	Database   HealthCheck     `json:"database"`
+	Migrations MigrationsCheck `json:"migrations"`
+	Pool       PoolCheck       `json:"pool"`
+}
+
+type HealthCheck struct {
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+type MigrationsCheck struct {
+	HealthCheck
+	Version         uint `json:"version"`
+	ExpectedVersion uint `json:"expected_version"`
+	Dirty           bool `json:"dirty"`
+}
+
+type PoolCheck struct {
+	HealthCheck
+	MaxOpenConnections int   `json:"max_open_connections"`
+	OpenConnections    int   `json:"open_connections"`
+	InUse              int   `json:"in_use"`
+	Idle               int   `json:"idle"`
+	WaitCount          int64 `json:"wait_count"`
+}
+
+// Liveness answers as long as the process can serve HTTP, without touching
+// any dependency, so a slow database never gets the API restarted.
+func (c *HealthHandler) Liveness(w http.ResponseWriter, req *http.Request) {
+	lib.RenderJSON(w, http.StatusOK, LivenessResponse{Status: HealthOK})
+}
+
+// Readiness answers 200 when the database answers, its schema is at the
+// expected migration and the connection pool has room left, and 503 with the
+// failing checks otherwise.
+func (c *HealthHandler) Readiness(w http.ResponseWriter, req *http.Request) {
+
+	newCtx, cancel := requestContext(req, c.timeout)
+	defer cancel()
+
+	checks := ReadinessChecks{
+		Database:   HealthCheck{Status: HealthOK},
+		Migrations: MigrationsCheck{HealthCheck: HealthCheck{Status: HealthOK}, ExpectedVersion: c.expectedVersion},
+		Pool:       PoolCheck{HealthCheck: HealthCheck{Status: HealthOK}},
+	}
+
+	if err := c.repository.Ping(newCtx); err != nil {
+		checks.Database = HealthCheck{Status: HealthFailing, Detail: lib.DatabaseUnreachableError}
+		checks.Migrations.HealthCheck = HealthCheck{Status: HealthFailing, Detail: lib.DatabaseUnreachableError}
+	} else if schemaVersion, err := c.repository.SchemaVersion(newCtx); err != nil {
+		checks.Migrations.HealthCheck = HealthCheck{Status: HealthFailing, Detail: lib.SchemaVersionError}
+	} else {
+		checks.Migrations.Version = schemaVersion.Version
+		checks.Migrations.Dirty = schemaVersion.Dirty
+		if schemaVersion.Dirty {
+			checks.Migrations.HealthCheck = HealthCheck{Status: HealthFailing, Detail: lib.SchemaDirtyError}
+		} else if schemaVersion.Version != c.expectedVersion {
+			checks.Migrations.HealthCheck = HealthCheck{Status: HealthFailing, Detail: lib.SchemaVersionError}
+		}
+	}
+
+	stats := c.repository.PoolStats()
+	checks.Pool.MaxOpenConnections = stats.MaxOpenConnections
+	checks.Pool.OpenConnections = stats.OpenConnections
+	checks.Pool.InUse = stats.InUse
+	checks.Pool.Idle = stats.Idle
+	checks.Pool.WaitCount = stats.WaitCount
+	if stats.Exhausted() {
+		checks.Pool.HealthCheck = HealthCheck{Status: HealthFailing, Detail: lib.PoolExhaustedError}
+	}
+
+	response := ReadinessResponse{Status: HealthOK, Checks: checks}
+	status := http.StatusOK
+	for _, check := range []HealthCheck{checks.Database, checks.Migrations.HealthCheck, checks.Pool.HealthCheck} {
+		if check.Status != HealthOK {
+			response.Status = HealthUnavailable
+			status = http.StatusServiceUnavailable
+		}
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	lib.RenderJSON(w, status, response)
+}
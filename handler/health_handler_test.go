@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/aniljaiswalcs/pismo/model"
+	"github.com/aniljaiswalcs/pismo/pkg/apperror"
+)
+
+type MockHealthRepository struct {
+	mock.Mock
+}
+
+func (m *MockHealthRepository) Ping(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func (m *MockHealthRepository) SchemaVersion(ctx context.Context) (*model.SchemaVersion, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(*model.SchemaVersion), args.Error(1)
+}
+
+func (m *MockHealthRepository) PoolStats() model.PoolStats {
+	args := m.Called()
+	return args.Get(0).(model.PoolStats)
+}
+
+func TestLiveness(t *testing.T) {
+	handler := NewHealthHandler(new(MockHealthRepository), 14, 0)
+
+	req, _ := http.NewRequest("GET", "/healthz", nil)
+	w := httptest.NewRecorder()
+	handler.Liveness(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"status":"ok"}`, w.Body.String())
+}
+
+func TestReadiness(t *testing.T) {
+	pool := model.PoolStats{MaxOpenConnections: 20, OpenConnections: 3, InUse: 1, Idle: 2, WaitCount: 5}
+	req, _ := http.NewRequest("GET", "/readyz", nil)
+	w := httptest.NewRecorder()
+
+	mockRepo := new(MockHealthRepository)
+	mockRepo.On("Ping", mock.Anything).Return(nil)
+	mockRepo.On("SchemaVersion", mock.Anything).Return(&model.SchemaVersion{Version: 14}, nil)
+	mockRepo.On("PoolStats").Return(pool)
+
+	handler := NewHealthHandler(mockRepo, 14, 0)
+	handler.Readiness(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+	assert.JSONEq(t, `{
+		"status": "ok",
+		"checks": {
+			"database": {"status": "ok"},
+			"migrations": {"status": "ok", "version": 14, "expected_version": 14, "dirty": false},
+			"pool": {"status": "ok", "max_open_connections": 20, "open_connections": 3, "in_use": 1, "idle": 2, "wait_count": 5}
+		}
+	}`, w.Body.String())
+}
+
+func TestReadinessFailures(t *testing.T) {
+	var scenarios = []struct {
+		description        string
+		pingError          error
+		schemaVersion      *model.SchemaVersion
+		schemaError        error
+		pool               model.PoolStats
+		expectedDatabase   string
+		expectedMigrations string
+		expectedPool       string
+	}{
+		{
+			"Database unreachable",
+			apperror.New(apperror.ErrUnavailable, "connection refused"),
+			nil,
+			nil,
+			model.PoolStats{MaxOpenConnections: 20},
+			HealthFailing,
+			HealthFailing,
+			HealthOK,
+		},
+		{
+			"Migrations never ran",
+			nil,
+			nil,
+			errors.New(`relation "schema_migrations" does not exist`),
+			model.PoolStats{MaxOpenConnections: 20},
+			HealthOK,
+			HealthFailing,
+			HealthOK,
+		},
+		{
+			"Schema behind the build",
+			nil,
+			&model.SchemaVersion{Version: 13},
+			nil,
+			model.PoolStats{MaxOpenConnections: 20},
+			HealthOK,
+			HealthFailing,
+			HealthOK,
+		},
+		{
+			"Dirty schema",
+			nil,
+			&model.SchemaVersion{Version: 14, Dirty: true},
+			nil,
+			model.PoolStats{MaxOpenConnections: 20},
+			HealthOK,
+			HealthFailing,
+			HealthOK,
+		},
+		{
+			"Pool exhausted",
+			nil,
+			&model.SchemaVersion{Version: 14},
+			nil,
+			model.PoolStats{MaxOpenConnections: 20, OpenConnections: 20, InUse: 20},
+			HealthOK,
+			HealthOK,
+			HealthFailing,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		mockRepo := new(MockHealthRepository)
+		mockRepo.On("Ping", mock.Anything).Return(scenario.pingError)
+		mockRepo.On("SchemaVersion", mock.Anything).Return(scenario.schemaVersion, scenario.schemaError)
+		mockRepo.On("PoolStats").Return(scenario.pool)
+
+		req, _ := http.NewRequest("GET", "/readyz", nil)
+		w := httptest.NewRecorder()
+
+		handler := NewHealthHandler(mockRepo, 14, 0)
+		handler.Readiness(w, req)
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code, scenario.description)
+
+		var response ReadinessResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, HealthUnavailable, response.Status, scenario.description)
+		assert.Equal(t, scenario.expectedDatabase, response.Checks.Database.Status, scenario.description)
+		assert.Equal(t, scenario.expectedMigrations, response.Checks.Migrations.Status, scenario.description)
+		assert.Equal(t, scenario.expectedPool, response.Checks.Pool.Status, scenario.description)
+		if scenario.pingError != nil {
+			mockRepo.AssertNotCalled(t, "SchemaVersion", mock.Anything)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/aniljaiswalcs/pismo/model"
+	"github.com/aniljaiswalcs/pismo/pkg/lib"
+	"github.com/aniljaiswalcs/pismo/repository"
+)
+
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+	// maxIdempotentRequestBytes reads one byte past the body limit so that
+	// decodeJSON still rejects oversized bodies.
+	maxIdempotentRequestBytes = maxRequestBodyBytes + 1
+)
+
+type IdempotencyHandler struct {
+	repository repository.IdempotencyRepository
+}
+
+func NewIdempotencyHandler(repository repository.IdempotencyRepository) *IdempotencyHandler {
+	return &IdempotencyHandler{
+		repository: repository,
+	}
+}
+
+// Middleware makes next safe to retry. Requests carrying an Idempotency-Key
+// header are executed once per key; replays with the same body get the stored
+// response back and replays with a different body are rejected. Requests
+// without the header go straight to next.
+func (c *IdempotencyHandler) Middleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		key := req.Header.Get(IdempotencyKeyHeader)
+		if key == "" {
+			next(w, req)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			renderProblem(w, http.StatusBadRequest, lib.CodeInvalidIdempotencyKey, lib.IdempotencyKeyError)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(req.Body, maxIdempotentRequestBytes))
+		if err != nil {
+			renderProblem(w, http.StatusBadRequest, lib.CodeMalformedBody, err.Error())
+			return
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := sha256.Sum256(body)
+		record := model.IdempotencyRecord{
+			Key:         key,
+			Scope:       req.Method + " " + req.URL.Path,
+			RequestHash: hex.EncodeToString(hash[:]),
+		}
+
+		existing, reserved, err := c.repository.ReserveKey(req.Context(), record)
+		if err != nil {
+			renderError(w, err, lib.CodeInternalError, lib.IdempotencyKeyReserveError, lib.IdempotencyKeyReserveError)
+			return
+		}
+
+		if !reserved {
+			if existing.RequestHash != record.RequestHash {
+				renderProblem(w, http.StatusUnprocessableEntity, lib.CodeIdempotencyKeyReused, lib.IdempotencyKeyReusedError)
+				return
+			}
+			if !existing.Completed() {
+				renderProblem(w, http.StatusConflict, lib.CodeIdempotencyKeyInFlight, lib.IdempotencyKeyInFlightError)
+				return
+			}
+
+			w.Header().Set("Content-Type", existing.ContentType)
+			w.Header().Set(IdempotentReplayedHeader, "true")
+			w.WriteHeader(existing.StatusCode)
+			w.Write(existing.ResponseBody)
+			return
+		}
+
+		recorder := &responseRecorder{StatusRecorder: lib.NewStatusRecorder(w)}
+		next(recorder, req)
+
+		// the outcome is stored even when the client already went away, which
+		// is exactly the case the key protects against; the repository bounds
+		// the call with its own timeout and the logs keep the request ID
+		ctx := context.WithoutCancel(req.Context())
+
+		// server errors are not final, so the key is freed for a retry
+		if recorder.StatusCode >= http.StatusInternalServerError {
+			if err := c.repository.ReleaseKey(ctx, record.Scope, record.Key); err != nil {
+				slog.ErrorContext(ctx, "IdempotencyHandler#Middleware: releasing key failed", "error", err)
+			}
+			return
+		}
+
+		record.StatusCode = recorder.StatusCode
+		record.ContentType = recorder.Header().Get("Content-Type")
+		record.ResponseBody = recorder.body.Bytes()
+		if err := c.repository.CompleteKey(ctx, record); err != nil {
+			slog.ErrorContext(ctx, "IdempotencyHandler#Middleware: storing response failed", "error", err)
+		}
+	}
+}
+
+// responseRecorder forwards the response to the client while keeping a copy
+// of its status code and body.
+type responseRecorder struct {
+	*lib.StatusRecorder
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.body.Write(data)
+	return r.StatusRecorder.Write(data)
+}
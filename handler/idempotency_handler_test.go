@@ -0,0 +1,194 @@
+package handler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/aniljaiswalcs/pismo/model"
+	"github.com/aniljaiswalcs/pismo/pkg/lib"
+)
+
+type MockIdempotencyRepository struct {
+	mock.Mock
+}
+
+func (m *MockIdempotencyRepository) ReserveKey(ctx context.Context, record model.IdempotencyRecord) (*model.IdempotencyRecord, bool, error) {
+	args := m.Called(ctx, record)
+	return args.Get(0).(*model.IdempotencyRecord), args.Bool(1), args.Error(2)
+}
+
+func (m *MockIdempotencyRepository) CompleteKey(ctx context.Context, record model.IdempotencyRecord) error {
+	args := m.Called(ctx, record)
+	return args.Error(0)
+}
+
+func (m *MockIdempotencyRepository) ReleaseKey(ctx context.Context, scope string, key string) error {
+	args := m.Called(ctx, scope, key)
+	return args.Error(0)
+}
+
+func (m *MockIdempotencyRepository) DeleteExpiredKeys(ctx context.Context, before time.Time) (int64, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func requestHash(body string) string {
+	hash := sha256.Sum256([]byte(body))
+	return hex.EncodeToString(hash[:])
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	const body = `{"document_number": "52998224725"}`
+	const scope = "POST /v1/accounts"
+
+	var scenarios = []struct {
+		description          string
+		existing             *model.IdempotencyRecord
+		reserved             bool
+		nextStatusCode       int
+		expectedStatusCode   int
+		expectedBody         string
+		expectedNextCalls    int
+		expectedCompleteCall bool
+		expectedReleaseCall  bool
+	}{
+		{
+			description:          "First request is executed and stored",
+			existing:             &model.IdempotencyRecord{},
+			reserved:             true,
+			nextStatusCode:       http.StatusCreated,
+			expectedStatusCode:   http.StatusCreated,
+			expectedBody:         `{"account_id":1}`,
+			expectedNextCalls:    1,
+			expectedCompleteCall: true,
+		},
+		{
+			description:         "Server errors free the key for a retry",
+			existing:            &model.IdempotencyRecord{},
+			reserved:            true,
+			nextStatusCode:      http.StatusInternalServerError,
+			expectedStatusCode:  http.StatusInternalServerError,
+			expectedBody:        `{"account_id":1}`,
+			expectedNextCalls:   1,
+			expectedReleaseCall: true,
+		},
+		{
+			description: "Replay returns the stored response",
+			existing: &model.IdempotencyRecord{
+				RequestHash:  requestHash(body),
+				StatusCode:   http.StatusCreated,
+				ContentType:  "application/json",
+				ResponseBody: []byte(`{"account_id":1}`),
+			},
+			expectedStatusCode: http.StatusCreated,
+			expectedBody:       `{"account_id":1}`,
+		},
+		{
+			description:        "Different body with the same key is rejected",
+			existing:           &model.IdempotencyRecord{RequestHash: requestHash(`{"document_number": "11222333000181"}`), StatusCode: http.StatusCreated},
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedBody:       problemBody(http.StatusUnprocessableEntity, lib.CodeIdempotencyKeyReused, lib.IdempotencyKeyReusedError),
+		},
+		{
+			description:        "Request still in flight",
+			existing:           &model.IdempotencyRecord{RequestHash: requestHash(body)},
+			expectedStatusCode: http.StatusConflict,
+			expectedBody:       problemBody(http.StatusConflict, lib.CodeIdempotencyKeyInFlight, lib.IdempotencyKeyInFlightError),
+		},
+	}
+
+	for _, scenario := range scenarios {
+		mockRepo := new(MockIdempotencyRepository)
+		handler := NewIdempotencyHandler(mockRepo)
+
+		mockRepo.On("ReserveKey", mock.Anything, model.IdempotencyRecord{
+			Key:         "key-1",
+			Scope:       scope,
+			RequestHash: requestHash(body),
+		}).Return(scenario.existing, scenario.reserved, nil)
+		mockRepo.On("CompleteKey", mock.Anything, model.IdempotencyRecord{
+			Key:          "key-1",
+			Scope:        scope,
+			RequestHash:  requestHash(body),
+			StatusCode:   scenario.nextStatusCode,
+			ContentType:  "application/json",
+			ResponseBody: []byte("{\"account_id\":1}\n"),
+		}).Return(nil)
+		mockRepo.On("ReleaseKey", mock.Anything, scope, "key-1").Return(nil)
+
+		nextCalls := 0
+		next := func(w http.ResponseWriter, req *http.Request) {
+			nextCalls++
+			lib.RenderJSON(w, scenario.nextStatusCode, map[string]int{"account_id": 1})
+		}
+
+		req, _ := http.NewRequest("POST", "/v1/accounts", strings.NewReader(body))
+		req.Header.Set(IdempotencyKeyHeader, "key-1")
+		w := httptest.NewRecorder()
+
+		handler.Middleware(next)(w, req)
+
+		assert.Equal(t, scenario.expectedStatusCode, w.Code, scenario.description)
+		assert.JSONEq(t, scenario.expectedBody, w.Body.String(), scenario.description)
+		assert.Equal(t, scenario.expectedNextCalls, nextCalls, scenario.description)
+		if scenario.expectedCompleteCall {
+			mockRepo.AssertCalled(t, "CompleteKey", mock.Anything, mock.Anything)
+		} else {
+			mockRepo.AssertNotCalled(t, "CompleteKey", mock.Anything, mock.Anything)
+		}
+		if scenario.expectedReleaseCall {
+			mockRepo.AssertCalled(t, "ReleaseKey", mock.Anything, scope, "key-1")
+		} else {
+			mockRepo.AssertNotCalled(t, "ReleaseKey", mock.Anything, scope, "key-1")
+		}
+	}
+}
+
+func TestIdempotencyMiddlewareWhenTheKeyCannotBeReserved(t *testing.T) {
+	mockRepo := new(MockIdempotencyRepository)
+	handler := NewIdempotencyHandler(mockRepo)
+	mockRepo.On("ReserveKey", mock.Anything, mock.Anything).Return((*model.IdempotencyRecord)(nil), false, errors.New("Error!"))
+
+	nextCalls := 0
+	next := func(w http.ResponseWriter, req *http.Request) {
+		nextCalls++
+	}
+
+	req, _ := http.NewRequest("POST", "/v1/accounts", strings.NewReader(`{}`))
+	req.Header.Set(IdempotencyKeyHeader, "key-1")
+	w := httptest.NewRecorder()
+	handler.Middleware(next)(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.JSONEq(t, problemBody(http.StatusInternalServerError, lib.CodeInternalError, lib.IdempotencyKeyReserveError), w.Body.String())
+	assert.Equal(t, 0, nextCalls)
+}
+
+func TestIdempotencyMiddlewareWithoutKey(t *testing.T) {
+	mockRepo := new(MockIdempotencyRepository)
+	handler := NewIdempotencyHandler(mockRepo)
+
+	nextCalls := 0
+	next := func(w http.ResponseWriter, req *http.Request) {
+		nextCalls++
+		w.WriteHeader(http.StatusCreated)
+	}
+
+	req, _ := http.NewRequest("POST", "/v1/accounts", strings.NewReader(`{}`))
+	w := httptest.NewRecorder()
+	handler.Middleware(next)(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, 1, nextCalls)
+	mockRepo.AssertNotCalled(t, "ReserveKey", mock.Anything, mock.Anything)
+}
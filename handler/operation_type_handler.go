@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aniljaiswalcs/pismo/model"
+	"github.com/aniljaiswalcs/pismo/pkg/lib"
+	"github.com/aniljaiswalcs/pismo/repository"
+)
+
+type OperationTypeHandler struct {
+	repository repository.OperationTypeRepository
+	registry   *model.OperationTypeRegistry
+	timeout    time.Duration
+}
+
+// NewOperationTypeHandler builds the handler. timeout bounds the repository
+// calls of each request; zero uses DefaultRequestTimeout.
+func NewOperationTypeHandler(repository repository.OperationTypeRepository, registry *model.OperationTypeRegistry, timeout time.Duration) *OperationTypeHandler {
+	return &OperationTypeHandler{
+		repository: repository,
+		registry:   registry,
+		timeout:    timeout,
+	}
+}
+
+// OperationTypeResponse is the public view of an operation type: the sign
+// tells whether its amount is negative (debit) or positive (credit).
+type OperationTypeResponse struct {
+	OperationTypeId uint32 `json:"operation_type_id"`
+	Description     string `json:"description"`
+	Sign            string `json:"sign"`
+}
+
+// GetOperationTypes returns the catalogue of operation types from the cached
+// registry. The response carries an ETag of its body, so clients revalidating
+// with If-None-Match get a 304 until an operation type is added.
+func (c *OperationTypeHandler) GetOperationTypes(w http.ResponseWriter, req *http.Request) {
+
+	catalogue := []OperationTypeResponse{}
+	for _, operationType := range c.registry.List() {
+		catalogue = append(catalogue, OperationTypeResponse{
+			OperationTypeId: operationType.OperationTypeId,
+			Description:     operationType.Description,
+			Sign:            operationType.Sign,
+		})
+	}
+
+	body := &bytes.Buffer{}
+	if err := json.NewEncoder(body).Encode(catalogue); err != nil {
+		panic(err)
+	}
+	hash := sha256.Sum256(body.Bytes())
+	etag := `"` + hex.EncodeToString(hash[:16]) + `"`
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+
+	if matchesETag(req.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(body.Bytes())
+}
+
+// matchesETag reports whether an If-None-Match header names etag, comparing
+// weakly as RFC 9110 requires for GET.
+func matchesETag(ifNoneMatch string, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *OperationTypeHandler) ListOperationTypes(w http.ResponseWriter, req *http.Request) {
+
+	newCtx, cancel := requestContext(req, c.timeout)
+	defer cancel()
+
+	operationTypes, err := c.repository.ListOperationTypes(newCtx)
+
+	if err != nil {
+		renderError(w, err, lib.CodeNotFound, lib.OperationTypeListError, lib.OperationTypeListError)
+		return
+	}
+
+	lib.RenderJSON(w, http.StatusOK, operationTypes)
+}
+
+// CreateOperationType stores a new operation type and makes it available to
+// new transactions right away.
+func (c *OperationTypeHandler) CreateOperationType(w http.ResponseWriter, req *http.Request) {
+
+	newCtx, cancel := requestContext(req, c.timeout)
+	defer cancel()
+
+	payload := &model.OperationType{}
+	if !decodeJSON(w, req, payload) {
+		return
+	}
+
+	if err := payload.Validate(); err != nil {
+		renderProblem(w, http.StatusBadRequest, lib.CodeInvalidOperationType, lib.OperationTypeDefinitionError)
+		return
+	}
+
+	operationType, err := c.repository.CreateOperationType(newCtx, *payload)
+
+	if err != nil {
+		renderError(w, err, lib.CodeNotFound, lib.OperationTypeCreationError, lib.OperationTypeCreationError)
+		return
+	}
+
+	c.registry.Add(*operationType)
+
+	lib.RenderJSON(w, http.StatusCreated, operationType)
+}
@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/aniljaiswalcs/pismo/model"
+	"github.com/aniljaiswalcs/pismo/pkg/lib"
+)
+
+type MockOperationTypeRepository struct {
+	mock.Mock
+}
+
+func (m *MockOperationTypeRepository) ListOperationTypes(ctx context.Context) ([]model.OperationType, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]model.OperationType), args.Error(1)
+}
+
+func (m *MockOperationTypeRepository) CreateOperationType(ctx context.Context, operationType model.OperationType) (*model.OperationType, error) {
+	args := m.Called(ctx, operationType)
+	return args.Get(0).(*model.OperationType), args.Error(1)
+}
+
+func TestListOperationTypes(t *testing.T) {
+	mockRepo := new(MockOperationTypeRepository)
+	mockRepo.On("ListOperationTypes", mock.Anything).Return(model.DefaultOperationTypes(), nil)
+
+	req, _ := http.NewRequest("GET", "/v1/admin/operation-types", nil)
+	w := httptest.NewRecorder()
+
+	handler := NewOperationTypeHandler(mockRepo, model.NewOperationTypeRegistry(nil), 0)
+	handler.ListOperationTypes(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var operationTypes []model.OperationType
+	json.Unmarshal(w.Body.Bytes(), &operationTypes)
+	assert.Equal(t, model.DefaultOperationTypes(), operationTypes)
+}
+
+func TestCreateOperationType(t *testing.T) {
+	cashback := model.OperationType{OperationTypeId: 7, Description: "Cashback", Sign: model.SIGN_CREDIT}
+
+	var scenarios = []struct {
+		description        string
+		payload            string
+		repositoryError    error
+		expectedStatusCode int
+		expectedResponse   string
+		expectedCached     bool
+	}{
+		{
+			"Operation type created and cached",
+			`{"operation_type_id": 7, "description": "Cashback", "sign": "credit"}`,
+			nil,
+			http.StatusCreated,
+			`{"operation_type_id":7,"description":"Cashback","sign":"credit","dischargeable":false,"consumes_credit_limit":false}`,
+			true,
+		},
+		{
+			"Credits cannot be discharged",
+			`{"operation_type_id": 7, "description": "Cashback", "sign": "credit", "dischargeable": true}`,
+			nil,
+			http.StatusBadRequest,
+			problemBody(http.StatusBadRequest, lib.CodeInvalidOperationType, lib.OperationTypeDefinitionError),
+			false,
+		},
+		{
+			"Duplicate id",
+			`{"operation_type_id": 7, "description": "Cashback", "sign": "credit"}`,
+			model.ErrOperationTypeExists,
+			http.StatusConflict,
+			problemBody(http.StatusConflict, lib.CodeOperationTypeExists, lib.OperationTypeExistsError),
+			false,
+		},
+		{
+			"Database failure",
+			`{"operation_type_id": 7, "description": "Cashback", "sign": "credit"}`,
+			errors.New("connection refused"),
+			http.StatusInternalServerError,
+			problemBody(http.StatusInternalServerError, lib.CodeInternalError, lib.OperationTypeCreationError),
+			false,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		mockRepo := new(MockOperationTypeRepository)
+		mockRepo.On("CreateOperationType", mock.Anything, cashback).Return(&cashback, scenario.repositoryError)
+		registry := model.NewOperationTypeRegistry(nil)
+
+		req, _ := http.NewRequest("POST", "/v1/admin/operation-types", strings.NewReader(scenario.payload))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		handler := NewOperationTypeHandler(mockRepo, registry, 0)
+		handler.CreateOperationType(w, req)
+
+		assert.Equal(t, scenario.expectedStatusCode, w.Code, scenario.description)
+		assert.JSONEq(t, scenario.expectedResponse, w.Body.String(), scenario.description)
+
+		_, cached := registry.Get(7)
+		assert.Equal(t, scenario.expectedCached, cached, scenario.description)
+	}
+}
+
+func TestGetOperationTypes(t *testing.T) {
+	registry := model.NewOperationTypeRegistry(model.DefaultOperationTypes()[:2])
+	handler := NewOperationTypeHandler(new(MockOperationTypeRepository), registry, 0)
+
+	req, _ := http.NewRequest("GET", "/v1/operation-types", nil)
+	w := httptest.NewRecorder()
+	handler.GetOperationTypes(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `[{"operation_type_id":1,"description":"Normal Purchase","sign":"debit"},{"operation_type_id":2,"description":"Purchase with installments","sign":"debit"}]`, w.Body.String())
+	etag := w.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	var scenarios = []struct {
+		description        string
+		ifNoneMatch        string
+		expectedStatusCode int
+	}{
+		{"Same ETag", etag, http.StatusNotModified},
+		{"Weak ETag in a list", `"other", W/` + etag, http.StatusNotModified},
+		{"Any ETag", "*", http.StatusNotModified},
+		{"Stale ETag", `"other"`, http.StatusOK},
+	}
+
+	for _, scenario := range scenarios {
+		req, _ := http.NewRequest("GET", "/v1/operation-types", nil)
+		req.Header.Set("If-None-Match", scenario.ifNoneMatch)
+		w := httptest.NewRecorder()
+		handler.GetOperationTypes(w, req)
+
+		assert.Equal(t, scenario.expectedStatusCode, w.Code, scenario.description)
+		assert.Equal(t, etag, w.Header().Get("ETag"), scenario.description)
+	}
+
+	registry.Add(model.OperationType{OperationTypeId: 7, Description: "Cashback", Sign: model.SIGN_CREDIT})
+	req, _ = http.NewRequest("GET", "/v1/operation-types", nil)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	handler.GetOperationTypes(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code, "a new operation type changes the ETag")
+	assert.NotEqual(t, etag, w.Header().Get("ETag"))
+}
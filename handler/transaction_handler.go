@@ -1,120 +1,327 @@
 package handler
 
 import (
-	"context"
-	"database/sql"
-	"encoding/json"
+	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/aniljaiswalcs/pismo/model"
 	"github.com/aniljaiswalcs/pismo/pkg/lib"
+	"github.com/aniljaiswalcs/pismo/pkg/money"
 	"github.com/aniljaiswalcs/pismo/repository"
 	"github.com/gorilla/mux"
 )
 
 type TransactionHandler struct {
 	repository repository.TransactionRepository
+	timeout    time.Duration
 }
 
-func NewTransactionHandler(repository repository.TransactionRepository) *TransactionHandler {
+// NewTransactionHandler builds the handler. timeout bounds the repository calls of each
+// request; zero uses DefaultRequestTimeout.
+func NewTransactionHandler(repository repository.TransactionRepository, timeout time.Duration) *TransactionHandler {
 	return &TransactionHandler{
 		repository: repository,
+		timeout:    timeout,
 	}
 }
 
 func (c *TransactionHandler) CreateTransaction(w http.ResponseWriter, req *http.Request) {
 	payload := &TransactionPayload{}
 
-	err := json.NewDecoder(req.Body).Decode(&payload)
-	if err != nil {
-		lib.RenderJSON(w, http.StatusBadRequest, err.Error())
+	if !decodeJSON(w, req, payload) {
+		return
 	}
 
 	payloadErrors := validatePayload(payload)
 
 	if len(payloadErrors) > 0 {
-		lib.RenderJSON(w, http.StatusBadRequest, payloadErrors)
+		renderValidationProblem(w, payloadErrors)
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 4*time.Second)
+	newTransaction := model.Transaction{
+		AccountId:        payload.AccountId,
+		OperationTypeId:  payload.OperationTypeId,
+		Amount:           payload.Amount,
+		Currency:         money.NormalizeCurrency(payload.Currency),
+		InstallmentCount: payload.Installments,
+	}
+	if payload.OriginalTransactionId != 0 {
+		newTransaction.OriginalTransactionId = &payload.OriginalTransactionId
+	}
+
+	ctx, cancel := requestContext(req, c.timeout)
 	defer cancel()
-	transaction, err := c.repository.CreateTransaction(ctx, model.Transaction{
-		AccountId:       payload.AccountId,
-		OperationTypeId: payload.OperationTypeId,
-		Amount:          payload.Amount,
-	})
+	transaction, err := c.repository.CreateTransaction(ctx, newTransaction)
 
 	if err != nil {
-		if err.Error() == lib.DatabaseTimeoutError {
-			lib.RenderJSON(w, http.StatusInternalServerError, lib.TimeoutError)
-			return
-		} else if err.Error() == lib.ContextDeadline {
-			lib.RenderJSON(w, http.StatusInternalServerError, lib.TimeoutError)
-			return
-		}
-		lib.RenderJSON(w, http.StatusBadRequest, lib.AccountIdNotFound)
+		renderError(w, err, lib.CodeAccountNotFound, lib.AccountIdNotFound, lib.TransactionCreationError)
 		return
 	}
 
 	lib.RenderJSON(w, http.StatusCreated, transaction)
 }
 
-func validatePayload(payload *TransactionPayload) []string {
-	var errors []string
+func validatePayload(payload *TransactionPayload) []lib.FieldError {
+	var errors []lib.FieldError
 
 	if payload.AccountId <= 0 {
-		errors = append(errors, lib.AccountIdValidation)
+		errors = append(errors, lib.FieldError{Field: "account_id", Code: lib.CodeInvalidAccountId, Detail: lib.AccountIdValidation})
 	}
 
 	if !model.ValidateOperationType(payload.OperationTypeId) {
-		errors = append(errors, lib.OperationTypeIdError)
+		errors = append(errors, lib.FieldError{Field: "operation_type_id", Code: lib.CodeInvalidOperationType, Detail: operationTypeIdError()})
 	}
 
 	if !model.ValidateOperationTypeAmount(payload.OperationTypeId, payload.Amount) {
-		errors = append(errors, lib.OperationTypeError)
+		errors = append(errors, lib.FieldError{Field: "amount", Code: lib.CodeInvalidAmount, Detail: lib.OperationTypeError})
+	}
+
+	if payload.Currency != "" && !money.IsCurrency(money.NormalizeCurrency(payload.Currency)) {
+		errors = append(errors, lib.FieldError{Field: "currency", Code: lib.CodeInvalidCurrency, Detail: lib.CurrencyError})
+	}
+
+	if !model.ValidateInstallments(payload.OperationTypeId, payload.Installments) {
+		errors = append(errors, lib.FieldError{Field: "installments", Code: lib.CodeInvalidInstallments, Detail: lib.InstallmentsError})
+	}
+
+	if model.RequiresOriginalTransaction(payload.OperationTypeId) {
+		if payload.OriginalTransactionId == 0 {
+			errors = append(errors, lib.FieldError{Field: "original_transaction_id", Code: lib.CodeOriginalTransactionRequired, Detail: lib.OriginalTransactionIdRequired})
+		}
+	} else if payload.OriginalTransactionId != 0 {
+		errors = append(errors, lib.FieldError{Field: "original_transaction_id", Code: lib.CodeOriginalTransactionNotAllowed, Detail: lib.OriginalTransactionIdNotAllowed})
 	}
 
 	return errors
 }
 
+// operationTypeIdError lists the operation types currently known to the
+// registry, so the message follows the operation_types table.
+func operationTypeIdError() string {
+	ids := []string{}
+	for _, operationType := range model.OperationTypes.List() {
+		ids = append(ids, strconv.FormatUint(uint64(operationType.OperationTypeId), 10))
+	}
+
+	return fmt.Sprintf(lib.OperationTypeIdError, strings.Join(ids, ", "))
+}
+
 type TransactionPayload struct {
-	AccountId       uint64  `json:"account_id"`
-	OperationTypeId uint32  `json:"operation_type_id"`
-	Amount          float32 `json:"amount"`
+	AccountId       uint64       `json:"account_id"`
+	OperationTypeId uint32       `json:"operation_type_id"`
+	Amount          money.Amount `json:"amount"`
+	// Currency defaults to the account currency when empty. Purchases and
+	// withdrawals in another currency are converted into the account currency.
+	Currency string `json:"currency"`
+	// OriginalTransactionId is required by reversals and refunds.
+	OriginalTransactionId uint64 `json:"original_transaction_id"`
+	// Installments splits an installment purchase into monthly installments;
+	// zero means a single installment.
+	Installments uint32 `json:"installments"`
 }
 
-func (c *TransactionHandler) GetAccount(w http.ResponseWriter, req *http.Request) {
+func (c *TransactionHandler) GetTransaction(w http.ResponseWriter, req *http.Request) {
 
-	newCtx, cancel := context.WithTimeout(req.Context(), 5*time.Second)
+	newCtx, cancel := requestContext(req, c.timeout)
 	defer cancel()
 
-	accountIdParam := mux.Vars(req)["transactionid"]
+	transactionIdParam := mux.Vars(req)["transactionid"]
+	transactionId, err := strconv.ParseUint(transactionIdParam, 10, 64)
+	if err != nil {
+		renderProblem(w, http.StatusBadRequest, lib.CodeInvalidTransactionId, lib.ParsingTransactionID)
+		return
+	}
+	if transactionId <= 0 {
+		renderProblem(w, http.StatusBadRequest, lib.CodeInvalidTransactionId, lib.TransactionIdValidation)
+		return
+	}
+
+	transaction, err := c.repository.FindtransactionAccount(newCtx, transactionId)
+
+	if err != nil {
+		renderError(w, err, lib.CodeTransactionNotFound, lib.TransactionIdNotFound, lib.DatabaseError)
+		return
+	}
+
+	lib.RenderJSON(w, http.StatusOK, transaction)
+}
+
+func (c *TransactionHandler) GetPaymentAllocations(w http.ResponseWriter, req *http.Request) {
+
+	newCtx, cancel := requestContext(req, c.timeout)
+	defer cancel()
+
+	transactionIdParam := mux.Vars(req)["transactionid"]
+	transactionId, err := strconv.ParseUint(transactionIdParam, 10, 64)
+	if err != nil {
+		renderProblem(w, http.StatusBadRequest, lib.CodeInvalidTransactionId, lib.ParsingTransactionID)
+		return
+	}
+	if transactionId <= 0 {
+		renderProblem(w, http.StatusBadRequest, lib.CodeInvalidTransactionId, lib.TransactionIdValidation)
+		return
+	}
+
+	allocations, err := c.repository.FindPaymentAllocations(newCtx, transactionId)
+
+	if err != nil {
+		renderError(w, err, lib.CodeTransactionNotFound, lib.TransactionIdNotFound, lib.DatabaseError)
+		return
+	}
+
+	lib.RenderJSON(w, http.StatusOK, allocations)
+}
+
+func (c *TransactionHandler) ListAccountTransactions(w http.ResponseWriter, req *http.Request) {
+
+	newCtx, cancel := requestContext(req, c.timeout)
+	defer cancel()
+
+	accountIdParam := mux.Vars(req)["accountId"]
 	accountId, err := strconv.ParseUint(accountIdParam, 10, 64)
 	if err != nil {
-		lib.RenderJSON(w, http.StatusBadRequest, lib.ParsingAccountID)
+		renderProblem(w, http.StatusBadRequest, lib.CodeInvalidAccountId, lib.ParsingAccountID)
 		return
 	}
 	if accountId <= 0 {
-		lib.RenderJSON(w, http.StatusBadRequest, lib.AccountIdValidation)
+		renderProblem(w, http.StatusBadRequest, lib.CodeInvalidAccountId, lib.AccountIdValidation)
+		return
+	}
+
+	filter, filterErrors := parseTransactionFilter(req.URL.Query())
+	if len(filterErrors) > 0 {
+		renderValidationProblem(w, filterErrors)
 		return
 	}
 
-	account, err := c.repository.FindtransactionAccount(newCtx, accountId)
+	page, err := c.repository.ListTransactions(newCtx, accountId, filter)
 
 	if err != nil {
-		if err == sql.ErrNoRows {
-			lib.RenderJSON(w, http.StatusNotFound, lib.AccountIdNotFound)
-			return
-		} else if err.Error() == lib.DatabaseTimeoutError || err.Error() == lib.ContextDeadline {
-			lib.RenderJSON(w, http.StatusInternalServerError, lib.TimeoutError)
-			return
-		}
-		lib.RenderJSON(w, http.StatusInternalServerError, lib.DatabaseError)
+		renderError(w, err, lib.CodeAccountNotFound, lib.AccountIdNotFound, lib.DatabaseError)
 		return
 	}
 
-	lib.RenderJSON(w, http.StatusOK, account)
+	lib.RenderJSON(w, http.StatusOK, page)
+}
+
+func (c *TransactionHandler) GetAccountBalance(w http.ResponseWriter, req *http.Request) {
+
+	newCtx, cancel := requestContext(req, c.timeout)
+	defer cancel()
+
+	accountIdParam := mux.Vars(req)["accountId"]
+	accountId, err := strconv.ParseUint(accountIdParam, 10, 64)
+	if err != nil {
+		renderProblem(w, http.StatusBadRequest, lib.CodeInvalidAccountId, lib.ParsingAccountID)
+		return
+	}
+	if accountId <= 0 {
+		renderProblem(w, http.StatusBadRequest, lib.CodeInvalidAccountId, lib.AccountIdValidation)
+		return
+	}
+
+	balance, err := c.repository.GetAccountBalance(newCtx, accountId)
+
+	if err != nil {
+		renderError(w, err, lib.CodeAccountNotFound, lib.AccountIdNotFound, lib.DatabaseError)
+		return
+	}
+
+	lib.RenderJSON(w, http.StatusOK, balance)
+}
+
+func parseTransactionFilter(query url.Values) (model.TransactionFilter, []lib.FieldError) {
+	var errors []lib.FieldError
+	filter := model.TransactionFilter{
+		Sort:  model.SORT_DESC,
+		Limit: model.DEFAULT_PAGE_SIZE,
+	}
+
+	if value := query.Get("operation_type_id"); value != "" {
+		operationTypeId, err := strconv.ParseUint(value, 10, 32)
+		if err != nil || operationTypeId == 0 {
+			errors = append(errors, lib.FieldError{Field: "operation_type_id", Code: lib.CodeInvalidFilter, Detail: lib.OperationTypeFilterError})
+		}
+		filter.OperationTypeId = uint32(operationTypeId)
+	}
+
+	createdFrom, fromErr := parseOptionalTime(query.Get("created_from"))
+	createdTo, toErr := parseOptionalTime(query.Get("created_to"))
+	if fromErr != nil {
+		errors = append(errors, lib.FieldError{Field: "created_from", Code: lib.CodeInvalidFilter, Detail: lib.CreatedAtFilterError})
+	}
+	if toErr != nil {
+		errors = append(errors, lib.FieldError{Field: "created_to", Code: lib.CodeInvalidFilter, Detail: lib.CreatedAtFilterError})
+	}
+	filter.CreatedFrom, filter.CreatedTo = createdFrom, createdTo
+
+	minAmount, minErr := parseOptionalAmount(query.Get("min_amount"))
+	maxAmount, maxErr := parseOptionalAmount(query.Get("max_amount"))
+	if minErr != nil {
+		errors = append(errors, lib.FieldError{Field: "min_amount", Code: lib.CodeInvalidFilter, Detail: lib.AmountFilterError})
+	}
+	if maxErr != nil {
+		errors = append(errors, lib.FieldError{Field: "max_amount", Code: lib.CodeInvalidFilter, Detail: lib.AmountFilterError})
+	}
+	filter.MinAmount, filter.MaxAmount = minAmount, maxAmount
+
+	if value := query.Get("open_only"); value != "" {
+		openOnly, err := strconv.ParseBool(value)
+		if err != nil {
+			errors = append(errors, lib.FieldError{Field: "open_only", Code: lib.CodeInvalidFilter, Detail: lib.OpenOnlyFilterError})
+		}
+		filter.OpenOnly = openOnly
+	}
+
+	if value := query.Get("sort"); value != "" {
+		if value != model.SORT_ASC && value != model.SORT_DESC {
+			errors = append(errors, lib.FieldError{Field: "sort", Code: lib.CodeInvalidFilter, Detail: lib.SortError})
+		}
+		filter.Sort = value
+	}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > model.MAX_PAGE_SIZE {
+			errors = append(errors, lib.FieldError{Field: "limit", Code: lib.CodeInvalidFilter, Detail: lib.LimitError})
+		}
+		filter.Limit = limit
+	}
+
+	if value := query.Get("cursor"); value != "" {
+		cursor, err := model.DecodeTransactionCursor(value)
+		if err != nil {
+			errors = append(errors, lib.FieldError{Field: "cursor", Code: lib.CodeInvalidFilter, Detail: lib.CursorError})
+		}
+		filter.After = &cursor
+	}
+
+	return filter, errors
+}
+
+func parseOptionalTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &parsed, nil
+}
+
+func parseOptionalAmount(value string) (*money.Amount, error) {
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := money.Parse(value)
+	if err != nil {
+		return nil, err
+	}
+	return &parsed, nil
 }
@@ -6,13 +6,18 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
-	"reflect"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
 	"github.com/aniljaiswalcs/pismo/model"
+	"github.com/aniljaiswalcs/pismo/pkg/apperror"
+	"github.com/aniljaiswalcs/pismo/pkg/lib"
+	"github.com/aniljaiswalcs/pismo/pkg/money"
 )
 
 type MockTransactionRepository struct {
@@ -34,6 +39,21 @@ func (m *MockTransactionRepository) FindtransactionAccount(ctx context.Context,
 	return args.Get(0).(*model.Transaction), args.Error(1)
 }
 
+func (m *MockTransactionRepository) FindPaymentAllocations(ctx context.Context, transactionId uint64) ([]model.PaymentAllocation, error) {
+	args := m.Called(ctx, transactionId)
+	return args.Get(0).([]model.PaymentAllocation), args.Error(1)
+}
+
+func (m *MockTransactionRepository) ListTransactions(ctx context.Context, accountId uint64, filter model.TransactionFilter) (*model.TransactionPage, error) {
+	args := m.Called(ctx, accountId, filter)
+	return args.Get(0).(*model.TransactionPage), args.Error(1)
+}
+
+func (m *MockTransactionRepository) GetAccountBalance(ctx context.Context, accountId uint64) (*model.AccountBalance, error) {
+	args := m.Called(ctx, accountId)
+	return args.Get(0).(*model.AccountBalance), args.Error(1)
+}
+
 func TestCreateTransaction(t *testing.T) {
 	mockRepo := new(MockTransactionRepository)
 
@@ -42,100 +62,113 @@ func TestCreateTransaction(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
 
-	expectedTransaction := &model.Transaction{AccountId: 123456789, OperationTypeId: 1, Amount: 100.0}
-	mockRepo.On("CreateTransaction", mock.Anything, mock.AnythingOfType("model.Transaction")).Return(expectedTransaction, nil)
+	expectedTransaction := &model.Transaction{TransactionId: 1, AccountId: 123456789, OperationTypeId: 1, Amount: money.MustParse("-100"), Balance: money.MustParse("-100"), Currency: "BRL"}
+	isPurchase := func(transaction model.Transaction) bool {
+		return transaction.AccountId == 123456789 && transaction.OperationTypeId == 1 && transaction.Amount == money.MustParse("-100")
+	}
+	mockRepo.On("CreateTransaction", mock.Anything, mock.MatchedBy(isPurchase)).Return(expectedTransaction, nil)
 
 	handler := &TransactionHandler{repository: mockRepo}
 	handler.CreateTransaction(w, req)
 
-	if w.Code != http.StatusCreated {
-		t.Errorf("Expected status code %d but got %d", http.StatusCreated, w.Code)
-	}
-
-	expectedResponse := `{"transaction_id":0,"account_id":123456789,"operation_type_id":1,"amount":100}`
-	actualResponse := w.Body.String()
-
-	expectedResponseJson := map[string]string{}
-	actualResponseJson := map[string]string{}
-
-	json.Unmarshal([]byte(expectedResponse), &expectedResponseJson)
-	json.Unmarshal([]byte(actualResponse), &actualResponseJson)
-
-	if !reflect.DeepEqual(expectedResponseJson, actualResponseJson) {
-		t.Errorf("Expected response body %s but got %s", expectedResponse, w.Body.String())
-	}
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.JSONEq(t, `{"transaction_id":1,"account_id":123456789,"operation_type_id":1,"amount":-100,"balance":-100,"currency":"BRL","created_at":"0001-01-01T00:00:00Z"}`, w.Body.String())
+	mockRepo.AssertExpectations(t)
 }
 
 func TestCreateTransactionFailsWhenInvalidRequest(t *testing.T) {
 	var scenarios = []struct {
-		payload            string
-		expectedResponse   string
-		expectedStatusCode int
+		payload        string
+		expectedCode   string
+		expectedErrors []lib.FieldError
 	}{
 		{
 			`{"account_id": 123456789, "operation_type_id": 1, "amount": 100.0}`,
-			`{"purchases and withdraw operations must have a negative amount. Payment operations must have a positive amount."}`,
-			http.StatusBadRequest,
+			lib.CodeValidationFailed,
+			[]lib.FieldError{{Field: "amount", Code: lib.CodeInvalidAmount, Detail: lib.OperationTypeError}},
 		},
 		{
 			`{"account_id": 123456789, "operation_type_id": 2, "amount": 100.0}`,
-			`{"purchases and withdraw operations must have a negative amount. Payment operations must have a positive amount."}`,
-			http.StatusBadRequest,
+			lib.CodeValidationFailed,
+			[]lib.FieldError{{Field: "amount", Code: lib.CodeInvalidAmount, Detail: lib.OperationTypeError}},
 		},
 		{
 			`{"account_id": 123456789, "operation_type_id": 3, "amount": 100.0}`,
-			`{"purchases and withdraw operations must have a negative amount. Payment operations must have a positive amount."}`,
-			http.StatusBadRequest,
+			lib.CodeValidationFailed,
+			[]lib.FieldError{{Field: "amount", Code: lib.CodeInvalidAmount, Detail: lib.OperationTypeError}},
 		},
 		{
 			`{"account_id": 123456789, "operation_type_id": 4, "amount": -100.0}`,
-			`{"purchases and withdraw operations must have a negative amount. Payment operations must have a positive amount."}`,
-			http.StatusBadRequest,
+			lib.CodeValidationFailed,
+			[]lib.FieldError{{Field: "amount", Code: lib.CodeInvalidAmount, Detail: lib.OperationTypeError}},
+		},
+		{
+			`{"account_id": 123456789, "operation_type_id": 6, "amount": 10.0}`,
+			lib.CodeValidationFailed,
+			[]lib.FieldError{{Field: "original_transaction_id", Code: lib.CodeOriginalTransactionRequired, Detail: lib.OriginalTransactionIdRequired}},
+		},
+		{
+			`{"account_id": 123456789, "operation_type_id": 2, "amount": -100.0, "installments": 25}`,
+			lib.CodeValidationFailed,
+			[]lib.FieldError{{Field: "installments", Code: lib.CodeInvalidInstallments, Detail: lib.InstallmentsError}},
+		},
+		{
+			`{"account_id": 123456789, "operation_type_id": 1, "amount": -100.0, "installments": 3}`,
+			lib.CodeValidationFailed,
+			[]lib.FieldError{{Field: "installments", Code: lib.CodeInvalidInstallments, Detail: lib.InstallmentsError}},
+		},
+		{
+			`{"account_id": 123456789, "operation_type_id": 1, "amount": -10.0, "original_transaction_id": 3}`,
+			lib.CodeValidationFailed,
+			[]lib.FieldError{{Field: "original_transaction_id", Code: lib.CodeOriginalTransactionNotAllowed, Detail: lib.OriginalTransactionIdNotAllowed}},
 		},
 		{
 			`{"account_id": "invalid", "operation_type_id": 1, "amount": -100.0}`,
-			`{"The account_id and operation_type_id must be valid positive integers. The amount must be a valid decimal."}`,
-			http.StatusBadRequest,
+			lib.CodeValidationFailed,
+			[]lib.FieldError{{Field: "account_id", Code: lib.CodeInvalidType, Detail: lib.InvalidTypeError}},
 		},
 		{
 			`{"account_id": -1, "operation_type_id": 1, "amount": -100.0}`,
-			`{"The account_id and operation_type_id must be valid positive integers. The amount must be a valid decimal."}`,
-			http.StatusBadRequest,
+			lib.CodeValidationFailed,
+			[]lib.FieldError{{Field: "account_id", Code: lib.CodeInvalidType, Detail: lib.InvalidTypeError}},
 		},
 		{
 			`{"account_id": 0, "operation_type_id": 1, "amount": -100.0}`,
-			`{"The account_id must be a valid positive integer."}`,
-			http.StatusBadRequest,
+			lib.CodeValidationFailed,
+			[]lib.FieldError{{Field: "account_id", Code: lib.CodeInvalidAccountId, Detail: lib.AccountIdValidation}},
 		},
 		{
 			`{"account_id": null, "operation_type_id": 1, "amount": -100.0}`,
-			`{"The account_id must be a valid positive integer."}`,
-			http.StatusBadRequest,
+			lib.CodeValidationFailed,
+			[]lib.FieldError{{Field: "account_id", Code: lib.CodeInvalidAccountId, Detail: lib.AccountIdValidation}},
 		},
 		{
 			`{"account_id": 123456789, "operation_type_id": 0, "amount": -100.0}`,
-			`{"The operation_type_id must be one of the following valid values: 1, 2, 3, 4"}`,
-			http.StatusBadRequest,
+			lib.CodeValidationFailed,
+			[]lib.FieldError{{Field: "operation_type_id", Code: lib.CodeInvalidOperationType, Detail: "the operation_type_id must be one of the following valid values: 1, 2, 3, 4, 5, 6"}},
 		},
 		{
 			`{"account_id": 123456789, "operation_type_id": 5, "amount": -100.0}`,
-			`{"The operation_type_id must be one of the following valid values: 1, 2, 3, 4"}`,
-			http.StatusBadRequest,
+			lib.CodeValidationFailed,
+			[]lib.FieldError{
+				{Field: "amount", Code: lib.CodeInvalidAmount, Detail: lib.OperationTypeError},
+				{Field: "original_transaction_id", Code: lib.CodeOriginalTransactionRequired, Detail: lib.OriginalTransactionIdRequired},
+			},
 		},
 		{
 			`{"account_id": 123456789, "operation_type_id": -1, "amount": -100.0}`,
-			`{"The account_id and operation_type_id must be valid positive integers. The amount must be a valid decimal."}`,
-			http.StatusBadRequest,
+			lib.CodeValidationFailed,
+			[]lib.FieldError{{Field: "operation_type_id", Code: lib.CodeInvalidType, Detail: lib.InvalidTypeError}},
 		},
 		{
 			`{"account_id": 123456789, "operation_type_id": null, "amount": -100.0}`,
-			`{"The operation_type_id must be one of the following valid values: 1, 2, 3, 4"}`,
-			http.StatusBadRequest,
+			lib.CodeValidationFailed,
+			[]lib.FieldError{{Field: "operation_type_id", Code: lib.CodeInvalidOperationType, Detail: "the operation_type_id must be one of the following valid values: 1, 2, 3, 4, 5, 6"}},
 		},
 		{
 			`{"account_id": 123456789, "operation_type_id": "invalid", "amount": -100.0}`,
-			`{"The account_id and operation_type_id must be valid positive integers. The amount must be a valid decimal."}`,
-			http.StatusBadRequest,
+			lib.CodeValidationFailed,
+			[]lib.FieldError{{Field: "operation_type_id", Code: lib.CodeInvalidType, Detail: lib.InvalidTypeError}},
 		},
 	}
 
@@ -150,61 +183,388 @@ func TestCreateTransactionFailsWhenInvalidRequest(t *testing.T) {
 		handler := &TransactionHandler{repository: mockRepo}
 		handler.CreateTransaction(w, req)
 
-		if w.Code != scenario.expectedStatusCode {
-			t.Errorf("Expected status code %d but got %d", http.StatusCreated, w.Code)
-		}
+		assert.Equal(t, http.StatusBadRequest, w.Code, scenario.payload)
+		assert.Equal(t, lib.ProblemContentType, w.Header().Get("Content-Type"), scenario.payload)
 
-		expectedResponse := scenario.expectedResponse
-		actualResponse := w.Body.String()
+		var problem lib.Problem
+		json.Unmarshal(w.Body.Bytes(), &problem)
+		assert.Equal(t, scenario.expectedCode, problem.Code, scenario.payload)
+		assert.Equal(t, scenario.expectedErrors, problem.Errors, scenario.payload)
+	}
+}
 
-		expectedResponseJson := map[string]string{}
-		actualResponseJson := map[string]string{}
+func TestCreateTransactionWhenTransactionCreatonFails(t *testing.T) {
+	var scenarios = []struct {
+		description        string
+		repositoryError    error
+		expectedStatusCode int
+		expectedResponse   string
+	}{
+		{
+			"Unexpected error",
+			errors.New("Error!"),
+			http.StatusInternalServerError,
+			problemBody(http.StatusInternalServerError, lib.CodeInternalError, lib.TransactionCreationError),
+		},
+		{
+			"Account not found",
+			apperror.ErrNotFound,
+			http.StatusNotFound,
+			problemBody(http.StatusNotFound, lib.CodeAccountNotFound, lib.AccountIdNotFound),
+		},
+		{
+			"Database unavailable",
+			apperror.ErrUnavailable,
+			http.StatusServiceUnavailable,
+			problemBody(http.StatusServiceUnavailable, lib.CodeUnavailable, lib.UnavailableError),
+		},
+	}
 
-		json.Unmarshal([]byte(expectedResponse), &expectedResponseJson)
-		json.Unmarshal([]byte(actualResponse), &actualResponseJson)
+	for _, scenario := range scenarios {
+		mockRepo := new(MockTransactionRepository)
 
-		if !reflect.DeepEqual(expectedResponseJson, actualResponseJson) {
-			t.Errorf("Expected response body %s but got %s", expectedResponse, w.Body.String())
-		}
+		payload := `{"account_id": 123456789, "operation_type_id": 1, "amount": -100.0}`
+		req, _ := http.NewRequest("POST", "/accounts", strings.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		mockRepo.On("CreateTransaction", mock.Anything, mock.AnythingOfType("model.Transaction")).Return(&model.Transaction{}, scenario.repositoryError)
+
+		handler := &TransactionHandler{repository: mockRepo}
+		handler.CreateTransaction(w, req)
+
+		assert.Equal(t, scenario.expectedStatusCode, w.Code, scenario.description)
+		assert.JSONEq(t, scenario.expectedResponse, w.Body.String(), scenario.description)
 	}
 }
 
-func TestCreateTransactionWhenTransactionCreatonFails(t *testing.T) {
+func TestCreateTransactionWhenCurrencyDoesNotMatchAccount(t *testing.T) {
 	mockRepo := new(MockTransactionRepository)
 
-	payload := `{"account_id": 123456789, "operation_type_id": 1, "amount": -100.0}`
-	req, _ := http.NewRequest("POST", "/accounts", strings.NewReader(payload))
+	payload := `{"account_id": 1, "operation_type_id": 1, "amount": -100.0, "currency": "usd"}`
+	req, _ := http.NewRequest("POST", "/v1/transactions", strings.NewReader(payload))
 	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
 
-	mockRepo.On("CreateTransaction", mock.Anything, mock.AnythingOfType("model.Transaction")).Return(&model.Transaction{}, errors.New("Error!"))
+	mockRepo.On("CreateTransaction", mock.Anything, model.Transaction{
+		AccountId:       1,
+		OperationTypeId: 1,
+		Amount:          money.MustParse("-100"),
+		Currency:        "USD",
+	}).Return(&model.Transaction{}, model.ErrCurrencyMismatch)
 
-	handler := &TransactionHandler{repository: mockRepo}
+	handler := NewTransactionHandler(mockRepo, 0)
 	handler.CreateTransaction(w, req)
 
-	if w.Code != http.StatusBadRequest {
-		t.Errorf("Expected status code %d but got %d", http.StatusCreated, w.Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.JSONEq(t, problemBody(http.StatusUnprocessableEntity, lib.CodeCurrencyMismatch, lib.CurrencyMismatchError), w.Body.String())
+}
+
+func TestCreateTransactionWhenCreditLimitIsExceeded(t *testing.T) {
+	mockRepo := new(MockTransactionRepository)
+
+	payload := `{"account_id": 1, "operation_type_id": 3, "amount": -5000}`
+	req, _ := http.NewRequest("POST", "/v1/transactions", strings.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	mockRepo.On("CreateTransaction", mock.Anything, mock.AnythingOfType("model.Transaction")).Return(&model.Transaction{}, model.ErrInsufficientCreditLimit)
+
+	handler := NewTransactionHandler(mockRepo, 0)
+	handler.CreateTransaction(w, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.JSONEq(t, problemBody(http.StatusUnprocessableEntity, lib.CodeInsufficientCreditLimit, lib.InsufficientCreditLimitError), w.Body.String())
+}
+
+func TestOperationTypeIdErrorFollowsRegistry(t *testing.T) {
+	defaults := model.OperationTypes
+	defer func() { model.OperationTypes = defaults }()
+
+	model.OperationTypes = model.NewOperationTypeRegistry(model.DefaultOperationTypes())
+	assert.Equal(t, "the operation_type_id must be one of the following valid values: 1, 2, 3, 4, 5, 6", operationTypeIdError())
+
+	model.OperationTypes.Add(model.OperationType{OperationTypeId: 7, Description: "Cashback", Sign: model.SIGN_CREDIT})
+	assert.Equal(t, "the operation_type_id must be one of the following valid values: 1, 2, 3, 4, 5, 6, 7", operationTypeIdError())
+}
+
+func TestCreateRefund(t *testing.T) {
+	var scenarios = []struct {
+		description        string
+		repositoryError    error
+		expectedStatusCode int
+		expectedResponse   string
+	}{
+		{"Refund created", nil, http.StatusCreated, ""},
+		{"Original not found", model.ErrOriginalTransactionNotFound, http.StatusUnprocessableEntity, problemBody(http.StatusUnprocessableEntity, lib.CodeOriginalTransactionNotFound, lib.OriginalTransactionNotFound)},
+		{"Original is a payment", model.ErrOriginalTransactionNotRefundable, http.StatusUnprocessableEntity, problemBody(http.StatusUnprocessableEntity, lib.CodeOriginalTransactionNotRefundable, lib.OriginalTransactionNotRefundable)},
+		{"Refund too large", model.ErrRefundExceedsOriginal, http.StatusUnprocessableEntity, problemBody(http.StatusUnprocessableEntity, lib.CodeRefundExceedsOriginal, lib.RefundExceedsOriginalError)},
 	}
 
-	expectedResponse := `{"no account found for the provided account ID"}`
-	actualResponse := w.Body.String()
+	for _, scenario := range scenarios {
+		mockRepo := new(MockTransactionRepository)
 
-	expectedResponseJson := map[string]string{}
-	actualResponseJson := map[string]string{}
+		originalTransactionId := uint64(3)
+		created := &model.Transaction{TransactionId: 8, AccountId: 1, OperationTypeId: model.REFUND, Amount: money.MustParse("10"), OriginalTransactionId: &originalTransactionId}
+		mockRepo.On("CreateTransaction", mock.Anything, model.Transaction{
+			AccountId:             1,
+			OperationTypeId:       model.REFUND,
+			Amount:                money.MustParse("10"),
+			OriginalTransactionId: &originalTransactionId,
+		}).Return(created, scenario.repositoryError)
+
+		payload := `{"account_id": 1, "operation_type_id": 6, "amount": 10, "original_transaction_id": 3}`
+		req, _ := http.NewRequest("POST", "/v1/transactions", strings.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
 
-	json.Unmarshal([]byte(expectedResponse), &expectedResponseJson)
-	json.Unmarshal([]byte(actualResponse), &actualResponseJson)
+		handler := NewTransactionHandler(mockRepo, 0)
+		handler.CreateTransaction(w, req)
+
+		assert.Equal(t, scenario.expectedStatusCode, w.Code, scenario.description)
+		if scenario.repositoryError != nil {
+			assert.JSONEq(t, scenario.expectedResponse, w.Body.String(), scenario.description)
+			continue
+		}
 
-	if !reflect.DeepEqual(expectedResponseJson, actualResponseJson) {
-		t.Errorf("Expected response body %s but got %s", expectedResponse, w.Body.String())
+		var responseTransaction model.Transaction
+		json.Unmarshal(w.Body.Bytes(), &responseTransaction)
+		assert.Equal(t, originalTransactionId, *responseTransaction.OriginalTransactionId, scenario.description)
 	}
 }
 
 func TestNewTransactionHandler(t *testing.T) {
 	repository := &MockTransactionRepository{}
-	handler := NewTransactionHandler(repository)
+	handler := NewTransactionHandler(repository, 0)
 
 	if handler.repository != repository {
 		t.Errorf("The repository field for the handler wasn't assigned. Expect %s but got %s", repository, handler.repository)
 	}
 }
+
+func TestGetTransaction(t *testing.T) {
+	mockRepo := new(MockTransactionRepository)
+	handler := NewTransactionHandler(mockRepo, 0)
+
+	expectedTransaction := &model.Transaction{TransactionId: 10, AccountId: 7, OperationTypeId: 1, Amount: money.MustParse("-50"), Balance: money.MustParse("-50"), Currency: "BRL"}
+	mockRepo.On("FindtransactionAccount", mock.Anything, uint64(10)).Return(expectedTransaction, nil)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/v1/transactions/{transactionid:[0-9]+}", handler.GetTransaction).Methods("GET")
+
+	req, _ := http.NewRequest("GET", "/v1/transactions/10", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"transaction_id":10,"account_id":7,"operation_type_id":1,"amount":-50,"balance":-50,"currency":"BRL","created_at":"0001-01-01T00:00:00Z"}`, w.Body.String())
+}
+
+func TestGetTransactionFailures(t *testing.T) {
+	var scenarios = []struct {
+		description        string
+		path               string
+		expectedStatusCode int
+		expectedResponse   string
+	}{
+		{"Transaction not found", "/v1/transactions/99", http.StatusNotFound, problemBody(http.StatusNotFound, lib.CodeTransactionNotFound, lib.TransactionIdNotFound)},
+		{"Transaction id zero", "/v1/transactions/0", http.StatusBadRequest, problemBody(http.StatusBadRequest, lib.CodeInvalidTransactionId, lib.TransactionIdValidation)},
+		{"Transaction id too large", "/v1/transactions/99999999999999999999", http.StatusBadRequest, problemBody(http.StatusBadRequest, lib.CodeInvalidTransactionId, lib.ParsingTransactionID)},
+	}
+
+	for _, scenario := range scenarios {
+		mockRepo := new(MockTransactionRepository)
+		mockRepo.On("FindtransactionAccount", mock.Anything, uint64(99)).Return((*model.Transaction)(nil), apperror.ErrNotFound)
+		handler := NewTransactionHandler(mockRepo, 0)
+
+		router := mux.NewRouter()
+		router.HandleFunc("/v1/transactions/{transactionid:[0-9]+}", handler.GetTransaction).Methods("GET")
+
+		req, _ := http.NewRequest("GET", scenario.path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, scenario.expectedStatusCode, w.Code, scenario.description)
+		assert.JSONEq(t, scenario.expectedResponse, w.Body.String(), scenario.description)
+	}
+}
+
+func TestGetPaymentAllocations(t *testing.T) {
+	mockRepo := new(MockTransactionRepository)
+	handler := NewTransactionHandler(mockRepo, 0)
+
+	expectedAllocations := []model.PaymentAllocation{
+		{AllocationId: 1, PaymentTransactionId: 10, DebtTransactionId: 7, Amount: money.MustParse("50")},
+		{AllocationId: 2, PaymentTransactionId: 10, DebtTransactionId: 3, Amount: money.MustParse("23.5")},
+	}
+	mockRepo.On("FindPaymentAllocations", mock.Anything, uint64(10)).Return(expectedAllocations, nil)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/v1/transactions/{transactionid:[0-9]+}/allocations", handler.GetPaymentAllocations).Methods("GET")
+
+	req, _ := http.NewRequest("GET", "/v1/transactions/10/allocations", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var responseAllocations []model.PaymentAllocation
+	if err := json.Unmarshal(w.Body.Bytes(), &responseAllocations); err != nil {
+		t.Errorf("Error unmarshalling response: %v", err)
+	}
+	assert.Equal(t, expectedAllocations, responseAllocations)
+}
+
+func TestGetPaymentAllocationsWhenTransactionNotFound(t *testing.T) {
+	mockRepo := new(MockTransactionRepository)
+	handler := NewTransactionHandler(mockRepo, 0)
+
+	mockRepo.On("FindPaymentAllocations", mock.Anything, uint64(99)).Return([]model.PaymentAllocation{}, apperror.ErrNotFound)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/v1/transactions/{transactionid:[0-9]+}/allocations", handler.GetPaymentAllocations).Methods("GET")
+
+	req, _ := http.NewRequest("GET", "/v1/transactions/99/allocations", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestListAccountTransactions(t *testing.T) {
+	mockRepo := new(MockTransactionRepository)
+	handler := NewTransactionHandler(mockRepo, 0)
+
+	createdFrom := time.Date(2023, time.May, 1, 0, 0, 0, 0, time.UTC)
+	minAmount := money.MustParse("-500")
+	cursor := model.TransactionCursor{CreatedAt: time.Date(2023, time.May, 3, 10, 0, 0, 0, time.UTC), TransactionId: 42}
+
+	expectedFilter := model.TransactionFilter{
+		OperationTypeId: model.CASH_PURCHASE,
+		CreatedFrom:     &createdFrom,
+		MinAmount:       &minAmount,
+		OpenOnly:        true,
+		Sort:            model.SORT_ASC,
+		Limit:           2,
+		After:           &cursor,
+	}
+	expectedPage := &model.TransactionPage{
+		Transactions: []model.Transaction{
+			{TransactionId: 43, AccountId: 7, OperationTypeId: 1, Amount: money.MustParse("-10"), Balance: money.MustParse("-10"), Currency: "BRL"},
+		},
+		NextCursor: "next",
+	}
+	mockRepo.On("ListTransactions", mock.Anything, uint64(7), expectedFilter).Return(expectedPage, nil)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/v1/accounts/{accountId:[0-9]+}/transactions", handler.ListAccountTransactions).Methods("GET")
+
+	target := "/v1/accounts/7/transactions?operation_type_id=1&created_from=2023-05-01T00:00:00Z&min_amount=-500&open_only=true&sort=asc&limit=2&cursor=" + cursor.Encode()
+	req, _ := http.NewRequest("GET", target, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var responsePage model.TransactionPage
+	if err := json.Unmarshal(w.Body.Bytes(), &responsePage); err != nil {
+		t.Errorf("Error unmarshalling response: %v", err)
+	}
+	assert.Equal(t, expectedPage.NextCursor, responsePage.NextCursor)
+	assert.Equal(t, expectedPage.Transactions[0].TransactionId, responsePage.Transactions[0].TransactionId)
+}
+
+func TestListAccountTransactionsFailsWhenInvalidFilter(t *testing.T) {
+	var scenarios = []struct {
+		query          string
+		expectedFields []string
+	}{
+		{"operation_type_id=abc", []string{"operation_type_id"}},
+		{"created_from=yesterday", []string{"created_from"}},
+		{"max_amount=1.00001", []string{"max_amount"}},
+		{"open_only=maybe", []string{"open_only"}},
+		{"sort=up&limit=0", []string{"sort", "limit"}},
+		{"cursor=!!", []string{"cursor"}},
+	}
+
+	for _, scenario := range scenarios {
+		mockRepo := new(MockTransactionRepository)
+		handler := NewTransactionHandler(mockRepo, 0)
+
+		router := mux.NewRouter()
+		router.HandleFunc("/v1/accounts/{accountId:[0-9]+}/transactions", handler.ListAccountTransactions).Methods("GET")
+
+		req, _ := http.NewRequest("GET", "/v1/accounts/7/transactions?"+scenario.query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, scenario.query)
+
+		var problem lib.Problem
+		json.Unmarshal(w.Body.Bytes(), &problem)
+		assert.Equal(t, lib.CodeValidationFailed, problem.Code, scenario.query)
+
+		fields := []string{}
+		for _, fieldError := range problem.Errors {
+			assert.Equal(t, lib.CodeInvalidFilter, fieldError.Code, scenario.query)
+			fields = append(fields, fieldError.Field)
+		}
+		assert.Equal(t, scenario.expectedFields, fields, scenario.query)
+		mockRepo.AssertNotCalled(t, "ListTransactions")
+	}
+}
+
+func TestGetAccountBalance(t *testing.T) {
+	var scenarios = []struct {
+		description        string
+		balance            *model.AccountBalance
+		err                error
+		expectedStatusCode int
+	}{
+		{
+			"Balance found",
+			&model.AccountBalance{
+				AccountId:       7,
+				Currency:        "BRL",
+				OutstandingDebt: money.MustParse("68.95"),
+				OperationTypes: []model.OperationTypeBalance{
+					{OperationTypeId: 1, OutstandingDebt: money.MustParse("68.95"), OpenTransactions: 2},
+				},
+			},
+			nil,
+			http.StatusOK,
+		},
+		{
+			"Account not found",
+			&model.AccountBalance{},
+			apperror.ErrNotFound,
+			http.StatusNotFound,
+		},
+		{
+			"Database error",
+			&model.AccountBalance{},
+			errors.New("Database error!"),
+			http.StatusInternalServerError,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		mockRepo := new(MockTransactionRepository)
+		handler := NewTransactionHandler(mockRepo, 0)
+		mockRepo.On("GetAccountBalance", mock.Anything, uint64(7)).Return(scenario.balance, scenario.err)
+
+		router := mux.NewRouter()
+		router.HandleFunc("/v1/accounts/{accountId:[0-9]+}/balance", handler.GetAccountBalance).Methods("GET")
+
+		req, _ := http.NewRequest("GET", "/v1/accounts/7/balance", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, scenario.expectedStatusCode, w.Code, scenario.description)
+		if scenario.err == nil {
+			var responseBalance model.AccountBalance
+			json.Unmarshal(w.Body.Bytes(), &responseBalance)
+			assert.Equal(t, *scenario.balance, responseBalance, scenario.description)
+		}
+	}
+}
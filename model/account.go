@@ -1,6 +1,36 @@
 package model
 
+import (
+	"time"
+
+	"github.com/aniljaiswalcs/pismo/pkg/money"
+)
+
 type Account struct {
-	AccountId      uint64 `json:"account_id,omitempty"`
-	DocumentNumber uint64 `json:"document_number"`
+	AccountId uint64 `json:"account_id,omitempty"`
+	// DocumentNumber holds the digits of a CPF or CNPJ without punctuation.
+	DocumentNumber string `json:"document_number"`
+	Currency       string `json:"currency"`
+	// Status is active, blocked or closed.
+	Status string `json:"status"`
+	// AvailableCreditLimit is spent by purchases and withdrawals and given
+	// back when payments discharge them. It is nil while no limit has been
+	// configured, and the account may then spend without bound.
+	AvailableCreditLimit *money.Amount `json:"available_credit_limit"`
+}
+
+// CoversSpending reports whether the available credit limit allows the
+// account to spend amount.
+func (a Account) CoversSpending(amount money.Amount) bool {
+	return a.AvailableCreditLimit == nil || amount <= *a.AvailableCreditLimit
+}
+
+// CreditLimitAudit records a manual change of the available credit limit.
+type CreditLimitAudit struct {
+	AuditId       uint64        `json:"audit_id"`
+	AccountId     uint64        `json:"account_id"`
+	PreviousLimit *money.Amount `json:"previous_limit"`
+	NewLimit      money.Amount  `json:"new_limit"`
+	Reason        string        `json:"reason"`
+	CreatedAt     time.Time     `json:"created_at"`
 }
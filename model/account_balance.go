@@ -0,0 +1,40 @@
+package model
+
+import "github.com/aniljaiswalcs/pismo/pkg/money"
+
+// AccountBalance summarises the open balances of an account's transactions.
+type AccountBalance struct {
+	AccountId uint64 `json:"account_id"`
+	Currency  string `json:"currency"`
+	// OutstandingDebt is what the account still owes, as a positive amount.
+	OutstandingDebt money.Amount `json:"outstanding_debt"`
+	// PaymentSurplus is the part of payments not used to settle any debt.
+	PaymentSurplus money.Amount           `json:"payment_surplus"`
+	OperationTypes []OperationTypeBalance `json:"operation_types"`
+}
+
+type OperationTypeBalance struct {
+	OperationTypeId  uint32       `json:"operation_type_id"`
+	OutstandingDebt  money.Amount `json:"outstanding_debt"`
+	PaymentSurplus   money.Amount `json:"payment_surplus"`
+	OpenTransactions uint64       `json:"open_transactions"`
+}
+
+// NewAccountBalance totals the per operation type breakdown of an account.
+func NewAccountBalance(account Account, operationTypes []OperationTypeBalance) AccountBalance {
+	balance := AccountBalance{
+		AccountId:      account.AccountId,
+		Currency:       account.Currency,
+		OperationTypes: operationTypes,
+	}
+	if balance.OperationTypes == nil {
+		balance.OperationTypes = []OperationTypeBalance{}
+	}
+
+	for _, operationType := range operationTypes {
+		balance.OutstandingDebt = balance.OutstandingDebt.Add(operationType.OutstandingDebt)
+		balance.PaymentSurplus = balance.PaymentSurplus.Add(operationType.PaymentSurplus)
+	}
+
+	return balance
+}
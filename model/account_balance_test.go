@@ -0,0 +1,34 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aniljaiswalcs/pismo/pkg/money"
+)
+
+func TestNewAccountBalance(t *testing.T) {
+	account := Account{AccountId: 1, Currency: "BRL"}
+	operationTypes := []OperationTypeBalance{
+		{OperationTypeId: CASH_PURCHASE, OutstandingDebt: money.MustParse("50.25"), OpenTransactions: 2},
+		{OperationTypeId: WITHDRAW, OutstandingDebt: money.MustParse("18.7"), OpenTransactions: 1},
+		{OperationTypeId: PAYMENT, PaymentSurplus: money.MustParse("10"), OpenTransactions: 1},
+	}
+
+	balance := NewAccountBalance(account, operationTypes)
+
+	if balance.OutstandingDebt != money.MustParse("68.95") {
+		t.Errorf("Expected outstanding debt 68.95 but got %s", balance.OutstandingDebt)
+	}
+	if balance.PaymentSurplus != money.MustParse("10") {
+		t.Errorf("Expected payment surplus 10 but got %s", balance.PaymentSurplus)
+	}
+	if !reflect.DeepEqual(balance.OperationTypes, operationTypes) {
+		t.Errorf("Expected the breakdown to be kept as is")
+	}
+
+	empty := NewAccountBalance(account, nil)
+	if !empty.OutstandingDebt.IsZero() || !empty.PaymentSurplus.IsZero() || empty.OperationTypes == nil {
+		t.Errorf("Expected an empty balance but got %v", empty)
+	}
+}
@@ -0,0 +1,44 @@
+package model
+
+const (
+	ACCOUNT_ACTIVE  = "active"
+	ACCOUNT_BLOCKED = "blocked"
+	ACCOUNT_CLOSED  = "closed"
+)
+
+func ValidateAccountStatus(status string) bool {
+	switch status {
+	case ACCOUNT_ACTIVE, ACCOUNT_BLOCKED, ACCOUNT_CLOSED:
+		return true
+	}
+
+	return false
+}
+
+// ValidateStatusTransition checks that an account may move from one status
+// to another. Active and blocked accounts can switch between each other or
+// be closed; closed accounts stay closed. Keeping the same status is allowed
+// so repeated requests are harmless.
+func ValidateStatusTransition(from string, to string) error {
+	if from == ACCOUNT_CLOSED && to != ACCOUNT_CLOSED {
+		return ErrInvalidStatusTransition
+	}
+
+	return nil
+}
+
+// AllowsTransaction checks whether an account in the given status accepts a
+// new transaction of the operation type. Blocked accounts only accept credits
+// such as payments, closed accounts accept nothing.
+func AllowsTransaction(status string, operationTypeId uint32) error {
+	switch status {
+	case ACCOUNT_CLOSED:
+		return ErrAccountClosed
+	case ACCOUNT_BLOCKED:
+		if !IsCredit(operationTypeId) {
+			return ErrAccountBlocked
+		}
+	}
+
+	return nil
+}
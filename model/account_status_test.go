@@ -0,0 +1,49 @@
+package model
+
+import "testing"
+
+func TestValidateStatusTransition(t *testing.T) {
+	var scenarios = []struct {
+		from          string
+		to            string
+		expectedError error
+	}{
+		{ACCOUNT_ACTIVE, ACCOUNT_BLOCKED, nil},
+		{ACCOUNT_BLOCKED, ACCOUNT_ACTIVE, nil},
+		{ACCOUNT_ACTIVE, ACCOUNT_CLOSED, nil},
+		{ACCOUNT_BLOCKED, ACCOUNT_CLOSED, nil},
+		{ACCOUNT_ACTIVE, ACCOUNT_ACTIVE, nil},
+		{ACCOUNT_CLOSED, ACCOUNT_CLOSED, nil},
+		{ACCOUNT_CLOSED, ACCOUNT_ACTIVE, ErrInvalidStatusTransition},
+		{ACCOUNT_CLOSED, ACCOUNT_BLOCKED, ErrInvalidStatusTransition},
+	}
+
+	for _, scenario := range scenarios {
+		if err := ValidateStatusTransition(scenario.from, scenario.to); err != scenario.expectedError {
+			t.Errorf("Expected error %v moving from %s to %s but got %v", scenario.expectedError, scenario.from, scenario.to, err)
+		}
+	}
+}
+
+func TestAllowsTransaction(t *testing.T) {
+	var scenarios = []struct {
+		status          string
+		operationTypeId uint32
+		expectedError   error
+	}{
+		{ACCOUNT_ACTIVE, CASH_PURCHASE, nil},
+		{ACCOUNT_ACTIVE, PAYMENT, nil},
+		{ACCOUNT_BLOCKED, CASH_PURCHASE, ErrAccountBlocked},
+		{ACCOUNT_BLOCKED, WITHDRAW, ErrAccountBlocked},
+		{ACCOUNT_BLOCKED, PAYMENT, nil},
+		{ACCOUNT_BLOCKED, REFUND, nil},
+		{ACCOUNT_CLOSED, CASH_PURCHASE, ErrAccountClosed},
+		{ACCOUNT_CLOSED, PAYMENT, ErrAccountClosed},
+	}
+
+	for _, scenario := range scenarios {
+		if err := AllowsTransaction(scenario.status, scenario.operationTypeId); err != scenario.expectedError {
+			t.Errorf("Expected error %v for operation type %d on a %s account but got %v", scenario.expectedError, scenario.operationTypeId, scenario.status, err)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/aniljaiswalcs/pismo/pkg/money"
+)
+
+func TestCoversSpending(t *testing.T) {
+	limit := money.MustParse("100")
+
+	var scenarios = []struct {
+		description string
+		limit       *money.Amount
+		amount      money.Amount
+		expected    bool
+	}{
+		{"No limit configured", nil, money.MustParse("1000000"), true},
+		{"Within the limit", &limit, money.MustParse("99.99"), true},
+		{"The whole limit", &limit, money.MustParse("100"), true},
+		{"Beyond the limit", &limit, money.MustParse("100.01"), false},
+	}
+
+	for _, scenario := range scenarios {
+		account := Account{AvailableCreditLimit: scenario.limit}
+		if covered := account.CoversSpending(scenario.amount); covered != scenario.expected {
+			t.Errorf("%s: expected %v but got %v", scenario.description, scenario.expected, covered)
+		}
+	}
+}
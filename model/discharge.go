@@ -0,0 +1,157 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/aniljaiswalcs/pismo/pkg/money"
+)
+
+const (
+	OLDEST_FIRST               = "oldest-first"
+	NEWEST_FIRST               = "newest-first"
+	PRIORITY_BY_OPERATION_TYPE = "priority-by-operation-type"
+)
+
+// DischargeStrategy decides in which order a payment settles the open debts
+// of an account.
+type DischargeStrategy interface {
+	Name() string
+	Order(debts []Transaction) []Transaction
+}
+
+// NewDischargeStrategy returns the strategy registered under name. An empty
+// name selects newest-first, the historical behaviour.
+func NewDischargeStrategy(name string) (DischargeStrategy, error) {
+	switch name {
+	case "", NEWEST_FIRST:
+		return NewestFirst{}, nil
+	case OLDEST_FIRST:
+		return OldestFirst{}, nil
+	case PRIORITY_BY_OPERATION_TYPE:
+		return PriorityByOperationType{Priority: []uint32{WITHDRAW, CASH_PURCHASE, INSTALLMENT_PURCHASE}}, nil
+	}
+
+	return nil, fmt.Errorf("unknown discharge strategy %q", name)
+}
+
+type OldestFirst struct{}
+
+func (OldestFirst) Name() string {
+	return OLDEST_FIRST
+}
+
+func (OldestFirst) Order(debts []Transaction) []Transaction {
+	ordered := append([]Transaction{}, debts...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return olderThan(ordered[i], ordered[j])
+	})
+	return ordered
+}
+
+type NewestFirst struct{}
+
+func (NewestFirst) Name() string {
+	return NEWEST_FIRST
+}
+
+func (NewestFirst) Order(debts []Transaction) []Transaction {
+	ordered := append([]Transaction{}, debts...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return olderThan(ordered[j], ordered[i])
+	})
+	return ordered
+}
+
+// PriorityByOperationType settles debts in the order of Priority, oldest
+// first inside the same operation type. Operation types missing from
+// Priority are settled last.
+type PriorityByOperationType struct {
+	Priority []uint32
+}
+
+func (PriorityByOperationType) Name() string {
+	return PRIORITY_BY_OPERATION_TYPE
+}
+
+func (p PriorityByOperationType) Order(debts []Transaction) []Transaction {
+	rank := func(operationTypeId uint32) int {
+		for index, priority := range p.Priority {
+			if priority == operationTypeId {
+				return index
+			}
+		}
+		return len(p.Priority)
+	}
+
+	ordered := append([]Transaction{}, debts...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		ri, rj := rank(ordered[i].OperationTypeId), rank(ordered[j].OperationTypeId)
+		if ri != rj {
+			return ri < rj
+		}
+		return olderThan(ordered[i], ordered[j])
+	})
+	return ordered
+}
+
+func olderThan(a, b Transaction) bool {
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.Before(b.CreatedAt)
+	}
+	return a.TransactionId < b.TransactionId
+}
+
+type DischargeResult struct {
+	// Remaining is the part of the payment left after settling the debts.
+	Remaining money.Amount
+	// Discharged holds the debts touched by the payment with their new balance.
+	Discharged  []Transaction
+	Allocations []PaymentAllocation
+}
+
+// Discharge settles the open debts with the payment amount in the order given
+// by the strategy. Debts carry a negative balance; the payment a positive
+// amount. It returns ErrCurrencyMismatch when any debt is in another currency
+// than the payment.
+func Discharge(payment Transaction, debts []Transaction, strategy DischargeStrategy) (DischargeResult, error) {
+	for _, debt := range debts {
+		if debt.Currency != payment.Currency {
+			return DischargeResult{}, ErrCurrencyMismatch
+		}
+	}
+
+	result := DischargeResult{
+		Remaining:   payment.Amount,
+		Discharged:  []Transaction{},
+		Allocations: []PaymentAllocation{},
+	}
+
+	for _, debt := range strategy.Order(debts) {
+		if !result.Remaining.IsPositive() {
+			break
+		}
+		if !debt.Balance.IsNegative() {
+			continue
+		}
+
+		allocated := result.Remaining
+		if result.Remaining.Add(debt.Balance).IsNegative() {
+			debt.Balance = debt.Balance.Add(result.Remaining)
+			result.Remaining = 0
+		} else {
+			allocated = debt.Balance.Neg()
+			result.Remaining = result.Remaining.Add(debt.Balance)
+			debt.Balance = 0
+		}
+
+		result.Discharged = append(result.Discharged, debt)
+		result.Allocations = append(result.Allocations, PaymentAllocation{
+			PaymentTransactionId: payment.TransactionId,
+			DebtTransactionId:    debt.TransactionId,
+			Amount:               allocated,
+		})
+	}
+
+	return result, nil
+}
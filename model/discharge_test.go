@@ -0,0 +1,153 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aniljaiswalcs/pismo/pkg/money"
+)
+
+func TestNewDischargeStrategy(t *testing.T) {
+	var scenarios = []struct {
+		name         string
+		expectedName string
+		expectError  bool
+	}{
+		{"", NEWEST_FIRST, false},
+		{NEWEST_FIRST, NEWEST_FIRST, false},
+		{OLDEST_FIRST, OLDEST_FIRST, false},
+		{PRIORITY_BY_OPERATION_TYPE, PRIORITY_BY_OPERATION_TYPE, false},
+		{"random", "", true},
+	}
+
+	for _, scenario := range scenarios {
+		strategy, err := NewDischargeStrategy(scenario.name)
+
+		if scenario.expectError {
+			if err == nil {
+				t.Errorf("Expected an error for strategy %q", scenario.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Unexpected error for strategy %q: %s", scenario.name, err)
+			continue
+		}
+		if strategy.Name() != scenario.expectedName {
+			t.Errorf("Expected strategy %s but got %s", scenario.expectedName, strategy.Name())
+		}
+	}
+}
+
+func TestDischarge(t *testing.T) {
+	day := func(d int) time.Time {
+		return time.Date(2023, time.January, d, 0, 0, 0, 0, time.UTC)
+	}
+	debts := []Transaction{
+		{TransactionId: 1, OperationTypeId: CASH_PURCHASE, Balance: money.MustParse("-50"), Currency: "BRL", CreatedAt: day(1)},
+		{TransactionId: 2, OperationTypeId: INSTALLMENT_PURCHASE, Balance: money.MustParse("-23.5"), Currency: "BRL", CreatedAt: day(2)},
+		{TransactionId: 3, OperationTypeId: WITHDRAW, Balance: money.MustParse("-18.7"), Currency: "BRL", CreatedAt: day(3)},
+	}
+
+	var scenarios = []struct {
+		description         string
+		strategy            DischargeStrategy
+		payment             money.Amount
+		expectedRemaining   money.Amount
+		expectedBalances    map[uint64]money.Amount
+		expectedAllocations []PaymentAllocation
+	}{
+		{
+			"oldest first partially settles the second debt",
+			OldestFirst{},
+			money.MustParse("60"),
+			money.MustParse("0"),
+			map[uint64]money.Amount{1: money.MustParse("0"), 2: money.MustParse("-13.5")},
+			[]PaymentAllocation{
+				{PaymentTransactionId: 9, DebtTransactionId: 1, Amount: money.MustParse("50")},
+				{PaymentTransactionId: 9, DebtTransactionId: 2, Amount: money.MustParse("10")},
+			},
+		},
+		{
+			"newest first settles the withdrawal before the purchases",
+			NewestFirst{},
+			money.MustParse("20"),
+			money.MustParse("0"),
+			map[uint64]money.Amount{3: money.MustParse("0"), 2: money.MustParse("-22.2")},
+			[]PaymentAllocation{
+				{PaymentTransactionId: 9, DebtTransactionId: 3, Amount: money.MustParse("18.7")},
+				{PaymentTransactionId: 9, DebtTransactionId: 2, Amount: money.MustParse("1.3")},
+			},
+		},
+		{
+			"priority settles withdrawals, then cash, then installments",
+			PriorityByOperationType{Priority: []uint32{WITHDRAW, CASH_PURCHASE, INSTALLMENT_PURCHASE}},
+			money.MustParse("70"),
+			money.MustParse("0"),
+			map[uint64]money.Amount{3: money.MustParse("0"), 1: money.MustParse("0"), 2: money.MustParse("-22.2")},
+			[]PaymentAllocation{
+				{PaymentTransactionId: 9, DebtTransactionId: 3, Amount: money.MustParse("18.7")},
+				{PaymentTransactionId: 9, DebtTransactionId: 1, Amount: money.MustParse("50")},
+				{PaymentTransactionId: 9, DebtTransactionId: 2, Amount: money.MustParse("1.3")},
+			},
+		},
+		{
+			"payment larger than every debt keeps the surplus",
+			OldestFirst{},
+			money.MustParse("100"),
+			money.MustParse("7.8"),
+			map[uint64]money.Amount{1: money.MustParse("0"), 2: money.MustParse("0"), 3: money.MustParse("0")},
+			[]PaymentAllocation{
+				{PaymentTransactionId: 9, DebtTransactionId: 1, Amount: money.MustParse("50")},
+				{PaymentTransactionId: 9, DebtTransactionId: 2, Amount: money.MustParse("23.5")},
+				{PaymentTransactionId: 9, DebtTransactionId: 3, Amount: money.MustParse("18.7")},
+			},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		payment := Transaction{TransactionId: 9, OperationTypeId: PAYMENT, Amount: scenario.payment, Currency: "BRL"}
+		result, err := Discharge(payment, debts, scenario.strategy)
+		if err != nil {
+			t.Errorf("%s: unexpected error %s", scenario.description, err)
+			continue
+		}
+
+		if result.Remaining != scenario.expectedRemaining {
+			t.Errorf("%s: expected remaining %s but got %s", scenario.description, scenario.expectedRemaining, result.Remaining)
+		}
+
+		balances := map[uint64]money.Amount{}
+		for _, debt := range result.Discharged {
+			balances[debt.TransactionId] = debt.Balance
+		}
+		if len(balances) != len(scenario.expectedBalances) {
+			t.Errorf("%s: expected balances %v but got %v", scenario.description, scenario.expectedBalances, balances)
+		}
+		for id, balance := range scenario.expectedBalances {
+			if balances[id] != balance {
+				t.Errorf("%s: expected balance %s for transaction %d but got %s", scenario.description, balance, id, balances[id])
+			}
+		}
+
+		if !reflect.DeepEqual(result.Allocations, scenario.expectedAllocations) {
+			t.Errorf("%s: expected allocations %v but got %v", scenario.description, scenario.expectedAllocations, result.Allocations)
+		}
+	}
+
+	if !reflect.DeepEqual(debts[0], Transaction{TransactionId: 1, OperationTypeId: CASH_PURCHASE, Balance: money.MustParse("-50"), Currency: "BRL", CreatedAt: day(1)}) {
+		t.Errorf("Discharge must not modify the debts it receives")
+	}
+}
+
+func TestDischargeFailsWhenCurrenciesDiffer(t *testing.T) {
+	debts := []Transaction{
+		{TransactionId: 1, OperationTypeId: CASH_PURCHASE, Balance: money.MustParse("-50"), Currency: "BRL"},
+	}
+	payment := Transaction{TransactionId: 9, OperationTypeId: PAYMENT, Amount: money.MustParse("50"), Currency: "USD"}
+
+	if _, err := Discharge(payment, debts, OldestFirst{}); err != ErrCurrencyMismatch {
+		t.Errorf("Expected %v but got %v", ErrCurrencyMismatch, err)
+	}
+}
@@ -0,0 +1,53 @@
+package model
+
+import "github.com/aniljaiswalcs/pismo/pkg/apperror"
+
+// Domain errors carry the apperror kind that decides their HTTP status:
+// broken business rules are validation errors and duplicates conflicts.
+var (
+	// ErrCurrencyMismatch is returned when a transaction is in a currency that
+	// differs from its account or from the debts it should discharge.
+	ErrCurrencyMismatch = apperror.New(apperror.ErrValidation, "transaction currency does not match the account currency")
+
+	// ErrExchangeRateUnavailable is returned when a foreign currency
+	// transaction cannot be converted because no rate is known.
+	ErrExchangeRateUnavailable = apperror.New(apperror.ErrValidation, "no exchange rate available for the transaction currency")
+
+	// ErrConvertedAmountOutOfRange is returned when a foreign currency amount
+	// is too large to be stored once converted to the account currency.
+	ErrConvertedAmountOutOfRange = apperror.New(apperror.ErrValidation, "converted amount out of range")
+
+	// ErrInsufficientCreditLimit is returned when a purchase or withdrawal
+	// exceeds the available credit limit of the account.
+	ErrInsufficientCreditLimit = apperror.New(apperror.ErrValidation, "insufficient available credit limit")
+
+	// ErrDocumentNumberExists is returned when an account is created for a
+	// document number that already has one.
+	ErrDocumentNumberExists = apperror.New(apperror.ErrConflict, "an account already exists for the document number")
+
+	// ErrAccountBlocked and ErrAccountClosed are returned when the account
+	// status does not accept the new transaction.
+	ErrAccountBlocked = apperror.New(apperror.ErrValidation, "account is blocked")
+	ErrAccountClosed  = apperror.New(apperror.ErrValidation, "account is closed")
+
+	// ErrInvalidStatusTransition is returned when an account cannot move to
+	// the requested status.
+	ErrInvalidStatusTransition = apperror.New(apperror.ErrValidation, "invalid account status transition")
+
+	// ErrOutstandingDebt is returned when closing an account that still owes
+	// money.
+	ErrOutstandingDebt = apperror.New(apperror.ErrValidation, "account has outstanding debt")
+
+	// ErrInvalidOperationType is returned when an operation type definition
+	// is incomplete or breaks the sign rules.
+	ErrInvalidOperationType = apperror.New(apperror.ErrValidation, "invalid operation type")
+
+	// ErrOperationTypeExists is returned when an operation type is added
+	// with an id that is already taken.
+	ErrOperationTypeExists = apperror.New(apperror.ErrConflict, "operation type already exists")
+
+	ErrOriginalTransactionNotFound      = apperror.New(apperror.ErrValidation, "original transaction not found")
+	ErrOriginalTransactionNotRefundable = apperror.New(apperror.ErrValidation, "original transaction cannot be reversed or refunded")
+	ErrRefundExceedsOriginal            = apperror.New(apperror.ErrValidation, "refund exceeds the amount left to give back on the original transaction")
+	ErrReversalNotFull                  = apperror.New(apperror.ErrValidation, "a reversal must give back the whole amount left on the original transaction")
+)
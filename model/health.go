@@ -0,0 +1,25 @@
+package model
+
+// SchemaVersion is the state golang-migrate keeps in schema_migrations. A
+// dirty schema means the last migration failed half-way.
+type SchemaVersion struct {
+	Version uint
+	Dirty   bool
+}
+
+// PoolStats describes the database connection pool.
+type PoolStats struct {
+	// MaxOpenConnections is zero when the pool has no limit.
+	MaxOpenConnections int
+	OpenConnections    int
+	InUse              int
+	Idle               int
+	// WaitCount is how many times a request had to wait for a connection.
+	WaitCount int64
+}
+
+// Exhausted tells whether every connection the pool may open is in use, so
+// a new request would have to wait for one.
+func (s PoolStats) Exhausted() bool {
+	return s.MaxOpenConnections > 0 && s.InUse >= s.MaxOpenConnections
+}
@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// IdempotencyRecord keeps the outcome of a request sent with an
+// Idempotency-Key header so retries can be answered with the same response.
+type IdempotencyRecord struct {
+	Key         string
+	Scope       string
+	RequestHash string
+	// StatusCode stays zero while the original request is still in flight.
+	StatusCode   int
+	ContentType  string
+	ResponseBody []byte
+	CreatedAt    time.Time
+}
+
+func (r IdempotencyRecord) Completed() bool {
+	return r.StatusCode != 0
+}
@@ -0,0 +1,63 @@
+package model
+
+import (
+	"time"
+)
+
+// MAX_INSTALLMENTS is the longest installment plan a purchase may be split into.
+const MAX_INSTALLMENTS = 24
+
+// ValidateInstallments checks the installment count requested for the
+// operation type. Only installment purchases may set it; zero means a single
+// installment.
+func ValidateInstallments(operationTypeId uint32, installments uint32) bool {
+	if operationTypeId != INSTALLMENT_PURCHASE {
+		return installments == 0
+	}
+
+	return installments <= MAX_INSTALLMENTS
+}
+
+// InstallmentSchedule splits an installment purchase into one child
+// transaction per month. Installment i is due i months after the purchase,
+// on the same day of the month or on the last day of shorter months, and the
+// last installment takes the rounding remainder of the split.
+func InstallmentSchedule(purchase Transaction) []Transaction {
+	count := int(purchase.InstallmentCount)
+	if count == 0 {
+		count = 1
+	}
+
+	installments := make([]Transaction, count)
+	for i, amount := range purchase.Amount.Split(count) {
+		dueDate := addMonths(purchase.CreatedAt, i+1)
+		parentTransactionId := purchase.TransactionId
+
+		installments[i] = Transaction{
+			AccountId:           purchase.AccountId,
+			OperationTypeId:     purchase.OperationTypeId,
+			Amount:              amount,
+			Balance:             amount,
+			Currency:            purchase.Currency,
+			ParentTransactionId: &parentTransactionId,
+			InstallmentNumber:   uint32(i + 1),
+			DueDate:             &dueDate,
+			CreatedAt:           purchase.CreatedAt,
+		}
+	}
+
+	return installments
+}
+
+// addMonths returns the date months after t, clamped to the last day of the
+// target month, so a purchase on January 31st is due on February 28th.
+func addMonths(t time.Time, months int) time.Time {
+	year, month, day := t.Date()
+	firstOfMonth := time.Date(year, month+time.Month(months), 1, 0, 0, 0, 0, time.UTC)
+	lastDay := firstOfMonth.AddDate(0, 1, -1).Day()
+	if day > lastDay {
+		day = lastDay
+	}
+
+	return time.Date(firstOfMonth.Year(), firstOfMonth.Month(), day, 0, 0, 0, 0, time.UTC)
+}
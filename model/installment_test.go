@@ -0,0 +1,115 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aniljaiswalcs/pismo/pkg/money"
+)
+
+func TestValidateInstallments(t *testing.T) {
+	var scenarios = []struct {
+		operationTypeId uint32
+		installments    uint32
+		expected        bool
+	}{
+		{INSTALLMENT_PURCHASE, 0, true},
+		{INSTALLMENT_PURCHASE, 12, true},
+		{INSTALLMENT_PURCHASE, MAX_INSTALLMENTS, true},
+		{INSTALLMENT_PURCHASE, MAX_INSTALLMENTS + 1, false},
+		{CASH_PURCHASE, 0, true},
+		{CASH_PURCHASE, 3, false},
+		{PAYMENT, 1, false},
+	}
+
+	for _, scenario := range scenarios {
+		if result := ValidateInstallments(scenario.operationTypeId, scenario.installments); result != scenario.expected {
+			t.Errorf("Expected %t for %d installments of operation type %d but got %t", scenario.expected, scenario.installments, scenario.operationTypeId, result)
+		}
+	}
+}
+
+func TestInstallmentSchedule(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	var scenarios = []struct {
+		description      string
+		amount           money.Amount
+		installments     uint32
+		createdAt        time.Time
+		expectedAmounts  []money.Amount
+		expectedDueDates []time.Time
+	}{
+		{
+			"remainder goes to the last installment",
+			money.MustParse("-100"),
+			3,
+			time.Date(2023, time.March, 10, 15, 30, 0, 0, time.UTC),
+			[]money.Amount{money.MustParse("-33.3333"), money.MustParse("-33.3333"), money.MustParse("-33.3334")},
+			[]time.Time{date(2023, time.April, 10), date(2023, time.May, 10), date(2023, time.June, 10)},
+		},
+		{
+			"due dates are clamped to the end of shorter months",
+			money.MustParse("-40"),
+			4,
+			date(2023, time.January, 31),
+			[]money.Amount{money.MustParse("-10"), money.MustParse("-10"), money.MustParse("-10"), money.MustParse("-10")},
+			[]time.Time{date(2023, time.February, 28), date(2023, time.March, 31), date(2023, time.April, 30), date(2023, time.May, 31)},
+		},
+		{
+			"plans cross the end of the year",
+			money.MustParse("-50"),
+			2,
+			date(2023, time.December, 15),
+			[]money.Amount{money.MustParse("-25"), money.MustParse("-25")},
+			[]time.Time{date(2024, time.January, 15), date(2024, time.February, 15)},
+		},
+		{
+			"no count means a single installment",
+			money.MustParse("-18.7"),
+			0,
+			date(2024, time.January, 31),
+			[]money.Amount{money.MustParse("-18.7")},
+			[]time.Time{date(2024, time.February, 29)},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		purchase := Transaction{
+			TransactionId:    7,
+			AccountId:        1,
+			OperationTypeId:  INSTALLMENT_PURCHASE,
+			Amount:           scenario.amount,
+			Currency:         "BRL",
+			InstallmentCount: scenario.installments,
+			CreatedAt:        scenario.createdAt,
+		}
+		installments := InstallmentSchedule(purchase)
+
+		if len(installments) != len(scenario.expectedAmounts) {
+			t.Errorf("%s: expected %d installments but got %d", scenario.description, len(scenario.expectedAmounts), len(installments))
+			continue
+		}
+
+		total := money.Amount(0)
+		for i, installment := range installments {
+			total = total.Add(installment.Amount)
+
+			if installment.Amount != scenario.expectedAmounts[i] || installment.Balance != scenario.expectedAmounts[i] {
+				t.Errorf("%s: expected installment %d of %s but got amount %s and balance %s", scenario.description, i+1, scenario.expectedAmounts[i], installment.Amount, installment.Balance)
+			}
+			if !installment.DueDate.Equal(scenario.expectedDueDates[i]) {
+				t.Errorf("%s: expected installment %d due on %s but got %s", scenario.description, i+1, scenario.expectedDueDates[i], installment.DueDate)
+			}
+			if installment.InstallmentNumber != uint32(i+1) || *installment.ParentTransactionId != 7 {
+				t.Errorf("%s: expected installment %d of transaction 7 but got %d of %d", scenario.description, i+1, installment.InstallmentNumber, *installment.ParentTransactionId)
+			}
+		}
+
+		if total != scenario.amount {
+			t.Errorf("%s: expected installments to add up to %s but got %s", scenario.description, scenario.amount, total)
+		}
+	}
+}
@@ -1,40 +1,164 @@
 package model
 
+import (
+	"sort"
+	"sync"
+
+	"github.com/aniljaiswalcs/pismo/pkg/money"
+)
+
 const CASH_PURCHASE = 1
 const INSTALLMENT_PURCHASE = 2
 const WITHDRAW = 3
 const PAYMENT = 4
+const REVERSAL = 5
+const REFUND = 6
 
-func ValidateOperationType(operationTypeId uint32) bool {
-	for _, operationType := range getOperationTypes() {
-		if operationType == operationTypeId {
-			return true
+const (
+	SIGN_DEBIT  = "debit"
+	SIGN_CREDIT = "credit"
+)
+
+// OperationType describes how transactions of a type behave. Debits carry a
+// negative amount and credits a positive one; dischargeable debits are the
+// open debts settled by payments.
+type OperationType struct {
+	OperationTypeId     uint32 `json:"operation_type_id"`
+	Description         string `json:"description"`
+	Sign                string `json:"sign"`
+	Dischargeable       bool   `json:"dischargeable"`
+	ConsumesCreditLimit bool   `json:"consumes_credit_limit"`
+}
+
+// Validate checks the definition before it is stored. Only debits can be
+// discharged or consume the credit limit.
+func (o OperationType) Validate() error {
+	if o.OperationTypeId == 0 || o.Description == "" {
+		return ErrInvalidOperationType
+	}
+
+	switch o.Sign {
+	case SIGN_DEBIT:
+		return nil
+	case SIGN_CREDIT:
+		if o.Dischargeable || o.ConsumesCreditLimit {
+			return ErrInvalidOperationType
 		}
+		return nil
 	}
 
-	return false
+	return ErrInvalidOperationType
 }
 
-func ValidateOperationTypeAmount(operationTypeId uint32, amount float32) bool {
-	switch operationTypeId {
-	case CASH_PURCHASE, INSTALLMENT_PURCHASE, WITHDRAW:
-		if amount >= 0 {
-			return false
-		}
-	case PAYMENT:
-		if amount <= 0 {
-			return false
-		}
+// DefaultOperationTypes are the operation types shipped with the first
+// migrations, used until the operation_types table has been loaded.
+func DefaultOperationTypes() []OperationType {
+	return []OperationType{
+		{CASH_PURCHASE, "Normal Purchase", SIGN_DEBIT, true, true},
+		{INSTALLMENT_PURCHASE, "Purchase with installments", SIGN_DEBIT, true, true},
+		{WITHDRAW, "Withdrawal", SIGN_DEBIT, true, true},
+		{PAYMENT, "Credit Voucher", SIGN_CREDIT, false, false},
+		{REVERSAL, "Reversal", SIGN_CREDIT, false, false},
+		{REFUND, "Refund", SIGN_CREDIT, false, false},
 	}
+}
 
-	return true
+// OperationTypeRegistry caches the operation types in memory so validating
+// a transaction never needs a database round trip.
+type OperationTypeRegistry struct {
+	mu    sync.RWMutex
+	types map[uint32]OperationType
+}
+
+func NewOperationTypeRegistry(types []OperationType) *OperationTypeRegistry {
+	registry := &OperationTypeRegistry{}
+	registry.Load(types)
+	return registry
 }
 
-func getOperationTypes() []uint32 {
-	return []uint32{
-		CASH_PURCHASE,
-		INSTALLMENT_PURCHASE,
-		WITHDRAW,
-		PAYMENT,
+// OperationTypes is the registry the validation functions below read from.
+// It starts with the default operation types and is reloaded from the
+// database at startup.
+var OperationTypes = NewOperationTypeRegistry(DefaultOperationTypes())
+
+// Load replaces every cached operation type.
+func (r *OperationTypeRegistry) Load(types []OperationType) {
+	cached := make(map[uint32]OperationType, len(types))
+	for _, operationType := range types {
+		cached[operationType.OperationTypeId] = operationType
 	}
+
+	r.mu.Lock()
+	r.types = cached
+	r.mu.Unlock()
+}
+
+// Add caches a single operation type, replacing any type with the same id.
+func (r *OperationTypeRegistry) Add(operationType OperationType) {
+	r.mu.Lock()
+	r.types[operationType.OperationTypeId] = operationType
+	r.mu.Unlock()
+}
+
+func (r *OperationTypeRegistry) Get(operationTypeId uint32) (OperationType, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	operationType, ok := r.types[operationTypeId]
+	return operationType, ok
+}
+
+// List returns the cached operation types ordered by id.
+func (r *OperationTypeRegistry) List() []OperationType {
+	r.mu.RLock()
+	types := make([]OperationType, 0, len(r.types))
+	for _, operationType := range r.types {
+		types = append(types, operationType)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(types, func(i, j int) bool {
+		return types[i].OperationTypeId < types[j].OperationTypeId
+	})
+	return types
+}
+
+func ValidateOperationType(operationTypeId uint32) bool {
+	_, ok := OperationTypes.Get(operationTypeId)
+	return ok
+}
+
+func ValidateOperationTypeAmount(operationTypeId uint32, amount money.Amount) bool {
+	operationType, ok := OperationTypes.Get(operationTypeId)
+	if !ok {
+		return true
+	}
+
+	switch operationType.Sign {
+	case SIGN_DEBIT:
+		return amount.IsNegative()
+	case SIGN_CREDIT:
+		return amount.IsPositive()
+	}
+
+	return true
+}
+
+// IsCredit reports whether the operation type puts money into the account.
+func IsCredit(operationTypeId uint32) bool {
+	operationType, ok := OperationTypes.Get(operationTypeId)
+	return ok && operationType.Sign == SIGN_CREDIT
+}
+
+// ConsumesCreditLimit reports whether the operation type spends the
+// available credit limit of the account.
+func ConsumesCreditLimit(operationTypeId uint32) bool {
+	operationType, ok := OperationTypes.Get(operationTypeId)
+	return ok && operationType.ConsumesCreditLimit
+}
+
+// RequiresOriginalTransaction reports whether the operation type undoes an
+// earlier transaction referenced by original_transaction_id.
+func RequiresOriginalTransaction(operationTypeId uint32) bool {
+	return operationTypeId == REVERSAL || operationTypeId == REFUND
 }
@@ -1,6 +1,10 @@
 package model
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/aniljaiswalcs/pismo/pkg/money"
+)
 
 func TestValidateOperationType(t *testing.T) {
 	var scenarios = []struct {
@@ -29,6 +33,14 @@ func TestValidateOperationType(t *testing.T) {
 		},
 		{
 			5,
+			true,
+		},
+		{
+			6,
+			true,
+		},
+		{
+			7,
 			false,
 		},
 	}
@@ -45,47 +57,47 @@ func TestValidateOperationType(t *testing.T) {
 func TestValidateOperationTypeAmount(t *testing.T) {
 	var scenarios = []struct {
 		operationTypeId  uint32
-		amount           float32
+		amount           money.Amount
 		expectedResponse bool
 	}{
 		{
 			1,
-			-100.0,
+			money.MustParse("-100.0"),
 			true,
 		},
 		{
 			2,
-			-100.0,
+			money.MustParse("-100.0"),
 			true,
 		},
 		{
 			3,
-			-100.0,
+			money.MustParse("-100.0"),
 			true,
 		},
 		{
 			4,
-			100.0,
+			money.MustParse("100.0"),
 			true,
 		},
 		{
 			1,
-			100.0,
+			money.MustParse("100.0"),
 			false,
 		},
 		{
 			2,
-			100.0,
+			money.MustParse("100.0"),
 			false,
 		},
 		{
 			3,
-			100.0,
+			money.MustParse("100.0"),
 			false,
 		},
 		{
 			4,
-			-100.0,
+			money.MustParse("-100.0"),
 			false,
 		},
 	}
@@ -98,3 +110,76 @@ func TestValidateOperationTypeAmount(t *testing.T) {
 		}
 	}
 }
+
+func TestConsumesCreditLimit(t *testing.T) {
+	var scenarios = []struct {
+		operationTypeId  uint32
+		expectedResponse bool
+	}{
+		{CASH_PURCHASE, true},
+		{INSTALLMENT_PURCHASE, true},
+		{WITHDRAW, true},
+		{PAYMENT, false},
+	}
+
+	for _, scenario := range scenarios {
+		response := ConsumesCreditLimit(scenario.operationTypeId)
+
+		if response != scenario.expectedResponse {
+			t.Errorf("Expected response to be %t for operation type %d but got %t", scenario.expectedResponse, scenario.operationTypeId, response)
+		}
+	}
+}
+
+func TestOperationTypeValidate(t *testing.T) {
+	var scenarios = []struct {
+		operationType OperationType
+		expectedError error
+	}{
+		{OperationType{7, "Cashback", SIGN_CREDIT, false, false}, nil},
+		{OperationType{8, "Annual fee", SIGN_DEBIT, true, false}, nil},
+		{OperationType{0, "Annual fee", SIGN_DEBIT, true, false}, ErrInvalidOperationType},
+		{OperationType{8, "", SIGN_DEBIT, true, false}, ErrInvalidOperationType},
+		{OperationType{8, "Annual fee", "fee", true, false}, ErrInvalidOperationType},
+		{OperationType{7, "Cashback", SIGN_CREDIT, true, false}, ErrInvalidOperationType},
+		{OperationType{7, "Cashback", SIGN_CREDIT, false, true}, ErrInvalidOperationType},
+	}
+
+	for _, scenario := range scenarios {
+		if err := scenario.operationType.Validate(); err != scenario.expectedError {
+			t.Errorf("Expected error %v for %+v but got %v", scenario.expectedError, scenario.operationType, err)
+		}
+	}
+}
+
+func TestOperationTypeRegistry(t *testing.T) {
+	defaults := OperationTypes
+	defer func() { OperationTypes = defaults }()
+
+	OperationTypes = NewOperationTypeRegistry(DefaultOperationTypes())
+	OperationTypes.Add(OperationType{7, "Cashback", SIGN_CREDIT, false, false})
+	OperationTypes.Add(OperationType{8, "Annual fee", SIGN_DEBIT, true, false})
+
+	if !ValidateOperationType(7) || !ValidateOperationType(8) || ValidateOperationType(9) {
+		t.Errorf("Expected operation types 7 and 8 to be valid and 9 to be invalid")
+	}
+	if !ValidateOperationTypeAmount(7, money.MustParse("5")) || ValidateOperationTypeAmount(7, money.MustParse("-5")) {
+		t.Errorf("Expected operation type 7 to require a positive amount")
+	}
+	if !ValidateOperationTypeAmount(8, money.MustParse("-5")) || ValidateOperationTypeAmount(8, money.MustParse("5")) {
+		t.Errorf("Expected operation type 8 to require a negative amount")
+	}
+	if !IsCredit(7) || IsCredit(8) || ConsumesCreditLimit(8) {
+		t.Errorf("Expected operation type 7 to be a credit and 8 a debit that does not consume the credit limit")
+	}
+
+	list := OperationTypes.List()
+	if len(list) != 8 || list[6].OperationTypeId != 7 || list[7].OperationTypeId != 8 {
+		t.Errorf("Expected the eight operation types ordered by id but got %v", list)
+	}
+
+	OperationTypes.Load([]OperationType{{CASH_PURCHASE, "Normal Purchase", SIGN_DEBIT, true, true}})
+	if ValidateOperationType(PAYMENT) {
+		t.Errorf("Expected Load to replace every cached operation type")
+	}
+}
@@ -0,0 +1,16 @@
+package model
+
+import (
+	"time"
+
+	"github.com/aniljaiswalcs/pismo/pkg/money"
+)
+
+// PaymentAllocation records how much of a payment was used to settle one debt.
+type PaymentAllocation struct {
+	AllocationId         uint64       `json:"allocation_id"`
+	PaymentTransactionId uint64       `json:"payment_transaction_id"`
+	DebtTransactionId    uint64       `json:"debt_transaction_id"`
+	Amount               money.Amount `json:"amount"`
+	CreatedAt            time.Time    `json:"created_at"`
+}
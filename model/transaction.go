@@ -1,9 +1,75 @@
 package model
 
+import (
+	"errors"
+	"time"
+
+	"github.com/aniljaiswalcs/pismo/pkg/money"
+)
+
 type Transaction struct {
-	TransactionId   uint64  `json:"transaction_id"`
-	AccountId       uint64  `json:"account_id"`
-	OperationTypeId uint32  `json:"operation_type_id"`
-	Amount          float32 `json:"amount"`
-	Balance         float32 `json:"balance"`
+	TransactionId   uint64       `json:"transaction_id"`
+	AccountId       uint64       `json:"account_id"`
+	OperationTypeId uint32       `json:"operation_type_id"`
+	Amount          money.Amount `json:"amount"`
+	Balance         money.Amount `json:"balance"`
+	Currency        string       `json:"currency"`
+	// The original values are only set for transactions converted from a
+	// foreign currency; Amount and Currency then hold the converted values.
+	OriginalAmount   *money.Amount `json:"original_amount,omitempty"`
+	OriginalCurrency string        `json:"original_currency,omitempty"`
+	ExchangeRate     *money.Rate   `json:"exchange_rate,omitempty"`
+	// OriginalTransactionId links reversals and refunds to the purchase or
+	// withdrawal they give back.
+	OriginalTransactionId *uint64 `json:"original_transaction_id,omitempty"`
+	// An installment purchase keeps the total amount with a zero balance;
+	// the debt lives in one child transaction per installment, which only
+	// payments made after its due date discharge.
+	InstallmentCount    uint32        `json:"installment_count,omitempty"`
+	Installments        []Transaction `json:"installments,omitempty"`
+	ParentTransactionId *uint64       `json:"parent_transaction_id,omitempty"`
+	InstallmentNumber   uint32        `json:"installment_number,omitempty"`
+	DueDate             *time.Time    `json:"due_date,omitempty"`
+	CreatedAt           time.Time     `json:"created_at"`
+}
+
+// ValidateRefund checks a reversal or refund against the original
+// transaction and the amount already given back for it. Refunds may give
+// back part of what is left, reversals must give back all of it.
+func ValidateRefund(refund Transaction, original Transaction, alreadyRefunded money.Amount) error {
+	// installments are given back through the purchase they belong to
+	if original.AccountId != refund.AccountId || !ConsumesCreditLimit(original.OperationTypeId) || original.ParentTransactionId != nil {
+		return ErrOriginalTransactionNotRefundable
+	}
+
+	refundable := original.Amount.Neg().Sub(alreadyRefunded)
+	if refund.Amount > refundable {
+		return ErrRefundExceedsOriginal
+	}
+	if refund.OperationTypeId == REVERSAL && refund.Amount != refundable {
+		return ErrReversalNotFull
+	}
+
+	return nil
+}
+
+// ConvertTo returns the transaction expressed in currency at the given rate,
+// keeping the original amount, the original currency and the applied rate.
+func (t Transaction) ConvertTo(currency string, rate money.Rate) (Transaction, error) {
+	converted, err := t.Amount.Convert(rate)
+	if errors.Is(err, money.ErrOutOfRange) {
+		return t, ErrConvertedAmountOutOfRange
+	}
+	if err != nil {
+		return t, err
+	}
+
+	original := t.Amount
+	t.OriginalAmount = &original
+	t.OriginalCurrency = t.Currency
+	t.ExchangeRate = &rate
+	t.Amount = converted
+	t.Currency = currency
+
+	return t, nil
 }
@@ -0,0 +1,88 @@
+package model
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aniljaiswalcs/pismo/pkg/money"
+)
+
+const (
+	SORT_ASC  = "asc"
+	SORT_DESC = "desc"
+
+	DEFAULT_PAGE_SIZE = 50
+	MAX_PAGE_SIZE     = 200
+)
+
+var ErrInvalidCursor = errors.New("invalid transaction cursor")
+
+// TransactionFilter narrows the history of an account. Zero values mean no
+// restriction on the field.
+type TransactionFilter struct {
+	OperationTypeId uint32
+	CreatedFrom     *time.Time
+	CreatedTo       *time.Time
+	MinAmount       *money.Amount
+	MaxAmount       *money.Amount
+	// OpenOnly keeps transactions whose balance has not been fully settled.
+	OpenOnly bool
+	Sort     string
+	Limit    int
+	// After resumes the listing right after the given position.
+	After *TransactionCursor
+}
+
+// TransactionCursor is the keyset position of a transaction in the history,
+// ordered by creation time and then id.
+type TransactionCursor struct {
+	CreatedAt     time.Time
+	TransactionId uint64
+}
+
+func CursorOf(transaction Transaction) TransactionCursor {
+	return TransactionCursor{
+		CreatedAt:     transaction.CreatedAt,
+		TransactionId: transaction.TransactionId,
+	}
+}
+
+// Encode returns the opaque string handed to clients as next_cursor.
+func (c TransactionCursor) Encode() string {
+	raw := strconv.FormatInt(c.CreatedAt.UnixNano(), 10) + ":" + strconv.FormatUint(c.TransactionId, 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func DecodeTransactionCursor(encoded string) (TransactionCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return TransactionCursor{}, ErrInvalidCursor
+	}
+
+	nanos, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return TransactionCursor{}, ErrInvalidCursor
+	}
+
+	unixNano, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return TransactionCursor{}, ErrInvalidCursor
+	}
+	transactionId, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return TransactionCursor{}, ErrInvalidCursor
+	}
+
+	return TransactionCursor{
+		CreatedAt:     time.Unix(0, unixNano).UTC(),
+		TransactionId: transactionId,
+	}, nil
+}
+
+type TransactionPage struct {
+	Transactions []Transaction `json:"transactions"`
+	NextCursor   string        `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,27 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTransactionCursor(t *testing.T) {
+	cursor := TransactionCursor{
+		CreatedAt:     time.Date(2023, time.May, 3, 10, 4, 5, 123456000, time.UTC),
+		TransactionId: 42,
+	}
+
+	decoded, err := DecodeTransactionCursor(cursor.Encode())
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if !decoded.CreatedAt.Equal(cursor.CreatedAt) || decoded.TransactionId != cursor.TransactionId {
+		t.Errorf("Expected cursor %v but got %v", cursor, decoded)
+	}
+
+	for _, invalid := range []string{"", "!!", "bm90LWEtY3Vyc29y", "MTo"} {
+		if _, err := DecodeTransactionCursor(invalid); err != ErrInvalidCursor {
+			t.Errorf("Expected %v for %q but got %v", ErrInvalidCursor, invalid, err)
+		}
+	}
+}
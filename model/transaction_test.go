@@ -0,0 +1,94 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/aniljaiswalcs/pismo/pkg/money"
+)
+
+func TestValidateRefund(t *testing.T) {
+	original := Transaction{TransactionId: 3, AccountId: 1, OperationTypeId: CASH_PURCHASE, Amount: money.MustParse("-100")}
+
+	var scenarios = []struct {
+		description     string
+		refund          Transaction
+		original        Transaction
+		alreadyRefunded money.Amount
+		expectedError   error
+	}{
+		{
+			"partial refund",
+			Transaction{AccountId: 1, OperationTypeId: REFUND, Amount: money.MustParse("40")},
+			original,
+			0,
+			nil,
+		},
+		{
+			"refund of what is left",
+			Transaction{AccountId: 1, OperationTypeId: REFUND, Amount: money.MustParse("60")},
+			original,
+			money.MustParse("40"),
+			nil,
+		},
+		{
+			"refund above what is left",
+			Transaction{AccountId: 1, OperationTypeId: REFUND, Amount: money.MustParse("60.01")},
+			original,
+			money.MustParse("40"),
+			ErrRefundExceedsOriginal,
+		},
+		{
+			"full reversal",
+			Transaction{AccountId: 1, OperationTypeId: REVERSAL, Amount: money.MustParse("100")},
+			original,
+			0,
+			nil,
+		},
+		{
+			"partial reversal",
+			Transaction{AccountId: 1, OperationTypeId: REVERSAL, Amount: money.MustParse("50")},
+			original,
+			0,
+			ErrReversalNotFull,
+		},
+		{
+			"original from another account",
+			Transaction{AccountId: 2, OperationTypeId: REFUND, Amount: money.MustParse("10")},
+			original,
+			0,
+			ErrOriginalTransactionNotRefundable,
+		},
+		{
+			"original is a payment",
+			Transaction{AccountId: 1, OperationTypeId: REFUND, Amount: money.MustParse("10")},
+			Transaction{TransactionId: 4, AccountId: 1, OperationTypeId: PAYMENT, Amount: money.MustParse("100")},
+			0,
+			ErrOriginalTransactionNotRefundable,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		err := ValidateRefund(scenario.refund, scenario.original, scenario.alreadyRefunded)
+
+		if err != scenario.expectedError {
+			t.Errorf("%s: expected error %v but got %v", scenario.description, scenario.expectedError, err)
+		}
+	}
+}
+
+func TestConvertTo(t *testing.T) {
+	transaction := Transaction{AccountId: 1, OperationTypeId: CASH_PURCHASE, Amount: money.MustParse("-100"), Currency: "USD"}
+
+	converted, err := transaction.ConvertTo("BRL", money.MustParseRate("4.9512"))
+	if err != nil {
+		t.Fatalf("Expected the conversion to succeed but got %v", err)
+	}
+	if converted.Amount != money.MustParse("-495.12") || converted.Currency != "BRL" || *converted.OriginalAmount != transaction.Amount {
+		t.Errorf("Expected -495.12 BRL converted from -100 USD but got %+v", converted)
+	}
+
+	transaction.Amount = money.MustParse("-90000000")
+	if _, err := transaction.ConvertTo("BRL", money.MustParseRate("4.9512")); err != ErrConvertedAmountOutOfRange {
+		t.Errorf("Expected %v but got %v", ErrConvertedAmountOutOfRange, err)
+	}
+}
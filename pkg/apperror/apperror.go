@@ -0,0 +1,58 @@
+// Package apperror defines the kinds of failure the API tells its clients
+// apart. Repositories translate driver and context errors into these kinds
+// and handlers choose the HTTP status from the kind alone, with errors.Is.
+package apperror
+
+import "errors"
+
+var (
+	// ErrNotFound is returned when the requested resource does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrConflict is returned when the request clashes with stored data,
+	// such as a duplicate key.
+	ErrConflict = errors.New("conflict")
+	// ErrValidation is returned when the request is well formed but breaks
+	// a business rule or references data that does not exist.
+	ErrValidation = errors.New("validation failed")
+	// ErrTimeout is returned when the operation ran out of time.
+	ErrTimeout = errors.New("timeout")
+	// ErrUnavailable is returned when a dependency such as the database
+	// cannot be reached; the request may succeed if retried later.
+	ErrUnavailable = errors.New("service unavailable")
+)
+
+// Error is an error of a given kind. errors.Is matches both the kind and the
+// wrapped cause.
+type Error struct {
+	Kind error
+	Err  error
+}
+
+// New returns a domain error of the given kind with its own message.
+func New(kind error, message string) error {
+	return &Error{Kind: kind, Err: errors.New(message)}
+}
+
+// Wrap classifies err as kind, keeping it as the cause.
+func Wrap(kind error, err error) error {
+	return &Error{Kind: kind, Err: err}
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
+
+// Kind returns the kind of err, or nil when err was never classified.
+func Kind(err error) error {
+	for _, kind := range []error{ErrNotFound, ErrConflict, ErrValidation, ErrTimeout, ErrUnavailable} {
+		if errors.Is(err, kind) {
+			return kind
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,44 @@
+package apperror
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestErrorMatchesKindAndCause(t *testing.T) {
+	err := fmt.Errorf("finding account: %w", Wrap(ErrNotFound, sql.ErrNoRows))
+
+	if !errors.Is(err, ErrNotFound) || !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected %v to match both its kind and its cause", err)
+	}
+	if errors.Is(err, ErrConflict) {
+		t.Errorf("Expected %v not to match another kind", err)
+	}
+	if err.Error() != "finding account: sql: no rows in result set" {
+		t.Errorf("Expected the message of the cause but got %q", err.Error())
+	}
+}
+
+func TestKind(t *testing.T) {
+	domainError := New(ErrValidation, "account is blocked")
+
+	var scenarios = []struct {
+		err      error
+		expected error
+	}{
+		{domainError, ErrValidation},
+		{fmt.Errorf("creating transaction: %w", domainError), ErrValidation},
+		{Wrap(ErrTimeout, errors.New("canceling statement")), ErrTimeout},
+		{ErrUnavailable, ErrUnavailable},
+		{errors.New("boom"), nil},
+		{nil, nil},
+	}
+
+	for _, scenario := range scenarios {
+		if kind := Kind(scenario.err); kind != scenario.expected {
+			t.Errorf("Expected kind %v for %v but got %v", scenario.expected, scenario.err, kind)
+		}
+	}
+}
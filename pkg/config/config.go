@@ -0,0 +1,284 @@
+// Package config loads the settings of the API from an optional JSON file
+// and environment variables, which take precedence over the file.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/aniljaiswalcs/pismo/model"
+	"github.com/aniljaiswalcs/pismo/pkg/fx"
+)
+
+const (
+	LOG_DEBUG = "debug"
+	LOG_INFO  = "info"
+	LOG_WARN  = "warn"
+	LOG_ERROR = "error"
+)
+
+// minAdminTokenLength keeps the admin token out of reach of guessing.
+const minAdminTokenLength = 32
+
+type Config struct {
+	Port        int      `json:"port"`
+	DatabaseURL string   `json:"database_url"`
+	Database    Database `json:"database"`
+	Timeouts    Timeouts `json:"timeouts"`
+	LogLevel    string   `json:"log_level"`
+	// DischargeStrategy is one of the strategies of model.NewDischargeStrategy.
+	DischargeStrategy string `json:"discharge_strategy"`
+	// FXRatesFile is an optional JSON file of exchange rates.
+	FXRatesFile       string   `json:"fx_rates_file"`
+	IdempotencyKeyTTL Duration `json:"idempotency_key_ttl"`
+	// AdminToken is the bearer token the /v1/admin endpoints require. They
+	// are not served while it is empty.
+	AdminToken string `json:"admin_token"`
+}
+
+// Database sizes the connection pool. Zero MaxOpenConns means no limit.
+type Database struct {
+	MaxOpenConns    int      `json:"max_open_conns"`
+	MaxIdleConns    int      `json:"max_idle_conns"`
+	ConnMaxLifetime Duration `json:"conn_max_lifetime"`
+	// ConnectTimeout is how long the API waits for the database at startup.
+	ConnectTimeout Duration `json:"connect_timeout"`
+}
+
+// Timeouts bounds each layer of a request: the HTTP server reading the
+// request and writing the response, the handler, and the database calls the
+// repositories make for it.
+type Timeouts struct {
+	Read    Duration `json:"read"`
+	Write   Duration `json:"write"`
+	Request Duration `json:"request"`
+	Query   Duration `json:"query"`
+	// Transaction bounds the database transactions that create transactions,
+	// discharge debts and sweep idempotency keys.
+	Transaction Duration `json:"transaction"`
+	// Shutdown is how long in-flight requests may take to finish once the
+	// API is asked to stop.
+	Shutdown Duration `json:"shutdown"`
+}
+
+// Duration is a time.Duration written as a string such as "5s" or "1h30m".
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("durations must be strings such as \"5s\": %s", data)
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+func (d Duration) Duration() time.Duration {
+	return time.Duration(d)
+}
+
+func Default() Config {
+	return Config{
+		Port: 3000,
+		Database: Database{
+			MaxOpenConns:    20,
+			MaxIdleConns:    10,
+			ConnMaxLifetime: Duration(30 * time.Minute),
+			ConnectTimeout:  Duration(time.Minute),
+		},
+		Timeouts: Timeouts{
+			Read:        Duration(10 * time.Second),
+			Write:       Duration(15 * time.Second),
+			Request:     Duration(5 * time.Second),
+			Query:       Duration(2 * time.Second),
+			Transaction: Duration(4 * time.Second),
+			Shutdown:    Duration(20 * time.Second),
+		},
+		LogLevel:          LOG_INFO,
+		DischargeStrategy: model.NEWEST_FIRST,
+		IdempotencyKeyTTL: Duration(24 * time.Hour),
+	}
+}
+
+// Load starts from the defaults, applies the JSON file named by CONFIG_FILE
+// when set, then the environment variables, and validates the result.
+func Load() (Config, error) {
+	config := Default()
+
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		if err := config.readFile(path); err != nil {
+			return Config{}, err
+		}
+	}
+
+	if err := config.readEnv(); err != nil {
+		return Config{}, err
+	}
+
+	if err := config.Validate(); err != nil {
+		return Config{}, err
+	}
+
+	return config, nil
+}
+
+func (c *Config) readFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("config: reading %s: %w", path, err)
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(c); err != nil {
+		return fmt.Errorf("config: parsing %s: %w", path, err)
+	}
+
+	return nil
+}
+
+func (c *Config) readEnv() error {
+	var errs []error
+
+	envInt("API_PORT", &c.Port, &errs)
+	envString("POSTGRESQL_URL", &c.DatabaseURL)
+	envInt("DB_MAX_OPEN_CONNS", &c.Database.MaxOpenConns, &errs)
+	envInt("DB_MAX_IDLE_CONNS", &c.Database.MaxIdleConns, &errs)
+	envDuration("DB_CONN_MAX_LIFETIME", &c.Database.ConnMaxLifetime, &errs)
+	envDuration("DB_CONNECT_TIMEOUT", &c.Database.ConnectTimeout, &errs)
+	envDuration("HTTP_READ_TIMEOUT", &c.Timeouts.Read, &errs)
+	envDuration("HTTP_WRITE_TIMEOUT", &c.Timeouts.Write, &errs)
+	envDuration("REQUEST_TIMEOUT", &c.Timeouts.Request, &errs)
+	envDuration("DB_QUERY_TIMEOUT", &c.Timeouts.Query, &errs)
+	envDuration("DB_TRANSACTION_TIMEOUT", &c.Timeouts.Transaction, &errs)
+	envDuration("SHUTDOWN_TIMEOUT", &c.Timeouts.Shutdown, &errs)
+	envString("LOG_LEVEL", &c.LogLevel)
+	envString("DISCHARGE_STRATEGY", &c.DischargeStrategy)
+	envString("FX_RATES_FILE", &c.FXRatesFile)
+	envDuration("IDEMPOTENCY_KEY_TTL", &c.IdempotencyKeyTTL, &errs)
+	envString("ADMIN_TOKEN", &c.AdminToken)
+
+	return errors.Join(errs...)
+}
+
+// The env helpers leave the setting untouched when the variable is unset or
+// empty.
+func envString(name string, value *string) {
+	if env := os.Getenv(name); env != "" {
+		*value = env
+	}
+}
+
+func envInt(name string, value *int, errs *[]error) {
+	env := os.Getenv(name)
+	if env == "" {
+		return
+	}
+
+	parsed, err := strconv.Atoi(env)
+	if err != nil {
+		*errs = append(*errs, fmt.Errorf("config: %s must be an integer, got %q", name, env))
+		return
+	}
+	*value = parsed
+}
+
+func envDuration(name string, value *Duration, errs *[]error) {
+	env := os.Getenv(name)
+	if env == "" {
+		return
+	}
+
+	parsed, err := time.ParseDuration(env)
+	if err != nil {
+		*errs = append(*errs, fmt.Errorf("config: %s must be a duration such as \"5s\", got %q", name, env))
+		return
+	}
+	*value = Duration(parsed)
+}
+
+// Validate reports every invalid setting at once.
+func (c Config) Validate() error {
+	var errs []error
+	invalid := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf("config: "+format, args...))
+	}
+
+	if c.Port < 1 || c.Port > 65535 {
+		invalid("port (API_PORT) must be between 1 and 65535, got %d", c.Port)
+	}
+	if c.DatabaseURL == "" {
+		invalid("database_url (POSTGRESQL_URL) is required")
+	}
+
+	if c.Database.MaxOpenConns < 0 {
+		invalid("database.max_open_conns (DB_MAX_OPEN_CONNS) must not be negative, got %d", c.Database.MaxOpenConns)
+	}
+	if c.Database.MaxIdleConns < 0 {
+		invalid("database.max_idle_conns (DB_MAX_IDLE_CONNS) must not be negative, got %d", c.Database.MaxIdleConns)
+	}
+	if c.Database.MaxOpenConns > 0 && c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		invalid("database.max_idle_conns (DB_MAX_IDLE_CONNS) must not exceed database.max_open_conns (DB_MAX_OPEN_CONNS)")
+	}
+	if c.Database.ConnMaxLifetime < 0 {
+		invalid("database.conn_max_lifetime (DB_CONN_MAX_LIFETIME) must not be negative")
+	}
+
+	timeouts := []struct {
+		name  string
+		value Duration
+	}{
+		{"database.connect_timeout (DB_CONNECT_TIMEOUT)", c.Database.ConnectTimeout},
+		{"timeouts.read (HTTP_READ_TIMEOUT)", c.Timeouts.Read},
+		{"timeouts.write (HTTP_WRITE_TIMEOUT)", c.Timeouts.Write},
+		{"timeouts.request (REQUEST_TIMEOUT)", c.Timeouts.Request},
+		{"timeouts.query (DB_QUERY_TIMEOUT)", c.Timeouts.Query},
+		{"timeouts.transaction (DB_TRANSACTION_TIMEOUT)", c.Timeouts.Transaction},
+		{"timeouts.shutdown (SHUTDOWN_TIMEOUT)", c.Timeouts.Shutdown},
+		{"idempotency_key_ttl (IDEMPOTENCY_KEY_TTL)", c.IdempotencyKeyTTL},
+	}
+	for _, timeout := range timeouts {
+		if timeout.value <= 0 {
+			invalid("%s must be positive, got %s", timeout.name, timeout.value.Duration())
+		}
+	}
+	if c.Timeouts.Write > 0 && c.Timeouts.Write <= c.Timeouts.Request {
+		invalid("timeouts.write (HTTP_WRITE_TIMEOUT) must be longer than timeouts.request (REQUEST_TIMEOUT)")
+	}
+
+	switch c.LogLevel {
+	case LOG_DEBUG, LOG_INFO, LOG_WARN, LOG_ERROR:
+	default:
+		invalid("log_level (LOG_LEVEL) must be one of debug, info, warn or error, got %q", c.LogLevel)
+	}
+
+	if c.AdminToken != "" && len(c.AdminToken) < minAdminTokenLength {
+		invalid("admin_token (ADMIN_TOKEN) must have at least %d characters", minAdminTokenLength)
+	}
+
+	if _, err := model.NewDischargeStrategy(c.DischargeStrategy); err != nil {
+		invalid("discharge_strategy (DISCHARGE_STRATEGY): %s", err)
+	}
+
+	if c.FXRatesFile != "" {
+		if _, err := fx.LoadRateFile(c.FXRatesFile); err != nil {
+			invalid("fx_rates_file (FX_RATES_FILE): %s", err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
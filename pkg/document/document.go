@@ -0,0 +1,93 @@
+// Package document validates the Brazilian taxpayer numbers accounts are
+// opened with: the CPF of a person (11 digits) and the CNPJ of a company
+// (14 digits). Both end with two check digits computed modulo 11.
+package document
+
+import (
+	"errors"
+	"strings"
+)
+
+var ErrInvalidDocument = errors.New("document: invalid CPF or CNPJ")
+
+// Normalize strips the punctuation of a formatted number, so
+// "123.456.789-09" and "12345678909" are the same document.
+func Normalize(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '.', '-', '/', ' ':
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(s))
+}
+
+// Parse normalizes s and returns its digits when they form a valid CPF or
+// CNPJ.
+func Parse(s string) (string, error) {
+	digits := Normalize(s)
+
+	switch len(digits) {
+	case 11:
+		if IsCPF(digits) {
+			return digits, nil
+		}
+	case 14:
+		if IsCNPJ(digits) {
+			return digits, nil
+		}
+	}
+
+	return "", ErrInvalidDocument
+}
+
+// IsCPF reports whether digits is an unformatted CPF with valid check digits.
+func IsCPF(digits string) bool {
+	if len(digits) != 11 || !isDigits(digits) || repeated(digits) {
+		return false
+	}
+
+	return checkDigit(digits[:9], []int{10, 9, 8, 7, 6, 5, 4, 3, 2}) == digits[9] &&
+		checkDigit(digits[:10], []int{11, 10, 9, 8, 7, 6, 5, 4, 3, 2}) == digits[10]
+}
+
+// IsCNPJ reports whether digits is an unformatted CNPJ with valid check
+// digits.
+func IsCNPJ(digits string) bool {
+	if len(digits) != 14 || !isDigits(digits) || repeated(digits) {
+		return false
+	}
+
+	return checkDigit(digits[:12], []int{5, 4, 3, 2, 9, 8, 7, 6, 5, 4, 3, 2}) == digits[12] &&
+		checkDigit(digits[:13], []int{6, 5, 4, 3, 2, 9, 8, 7, 6, 5, 4, 3, 2}) == digits[13]
+}
+
+// checkDigit weighs the digits, takes the sum modulo 11 and maps remainders
+// below 2 to zero, the rule shared by CPF and CNPJ.
+func checkDigit(digits string, weights []int) byte {
+	sum := 0
+	for i, weight := range weights {
+		sum += int(digits[i]-'0') * weight
+	}
+
+	remainder := sum % 11
+	if remainder < 2 {
+		return '0'
+	}
+	return byte('0' + 11 - remainder)
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// repeated reports numbers made of a single digit, such as 111.111.111-11,
+// which pass the checksum but are never issued.
+func repeated(digits string) bool {
+	return strings.Count(digits, digits[:1]) == len(digits)
+}
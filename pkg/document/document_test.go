@@ -0,0 +1,37 @@
+package document
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	var scenarios = []struct {
+		input          string
+		expectedDigits string
+		expectedError  error
+	}{
+		{"529.982.247-25", "52998224725", nil},
+		{"52998224725", "52998224725", nil},
+		{"111.444.777-35", "11144477735", nil},
+		{"11.222.333/0001-81", "11222333000181", nil},
+		{"11222333000181", "11222333000181", nil},
+		{"529.982.247-24", "", ErrInvalidDocument},
+		{"11.222.333/0001-80", "", ErrInvalidDocument},
+		{"111.111.111-11", "", ErrInvalidDocument},
+		{"00000000000000", "", ErrInvalidDocument},
+		{"5299822472", "", ErrInvalidDocument},
+		{"5299822472a", "", ErrInvalidDocument},
+		{"44", "", ErrInvalidDocument},
+		{"", "", ErrInvalidDocument},
+	}
+
+	for _, scenario := range scenarios {
+		digits, err := Parse(scenario.input)
+
+		if err != scenario.expectedError {
+			t.Errorf("Expected error %v for %q but got %v", scenario.expectedError, scenario.input, err)
+			continue
+		}
+		if digits != scenario.expectedDigits {
+			t.Errorf("Expected %q for %q but got %q", scenario.expectedDigits, scenario.input, digits)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+// Package fx provides the exchange rates used to convert foreign currency
+// transactions into the currency of their account.
+package fx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aniljaiswalcs/pismo/pkg/money"
+)
+
+var ErrRateNotFound = errors.New("fx: exchange rate not found")
+
+type RateProvider interface {
+	// Rate returns how many units of to one unit of from is worth.
+	Rate(ctx context.Context, from, to string) (money.Rate, error)
+}
+
+// StaticRateProvider serves a fixed set of rates kept in memory. It is meant
+// for tests and local runs.
+type StaticRateProvider struct {
+	rates map[string]money.Rate
+}
+
+// NewStaticRateProvider builds a provider from rates keyed by "FROM/TO",
+// e.g. "USD/BRL".
+func NewStaticRateProvider(rates map[string]money.Rate) *StaticRateProvider {
+	normalized := make(map[string]money.Rate, len(rates))
+	for pair, rate := range rates {
+		normalized[strings.ToUpper(pair)] = rate
+	}
+
+	return &StaticRateProvider{
+		rates: normalized,
+	}
+}
+
+// LoadRateFile reads a JSON object of "FROM/TO" pairs to rates, e.g.
+// {"USD/BRL": 4.9512, "EUR/BRL": "5.3107"}.
+func LoadRateFile(path string) (*StaticRateProvider, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("fx: reading rate file: %w", err)
+	}
+
+	rates := map[string]money.Rate{}
+	if err := json.Unmarshal(content, &rates); err != nil {
+		return nil, fmt.Errorf("fx: parsing rate file %s: %w", path, err)
+	}
+
+	for pair := range rates {
+		from, to, ok := strings.Cut(pair, "/")
+		if !ok || !money.IsCurrency(money.NormalizeCurrency(from)) || !money.IsCurrency(money.NormalizeCurrency(to)) {
+			return nil, fmt.Errorf("fx: invalid currency pair %q in %s", pair, path)
+		}
+	}
+
+	return NewStaticRateProvider(rates), nil
+}
+
+func (p *StaticRateProvider) Rate(ctx context.Context, from, to string) (money.Rate, error) {
+	if from == to {
+		return money.IdentityRate, nil
+	}
+
+	rate, ok := p.rates[from+"/"+to]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s/%s", ErrRateNotFound, from, to)
+	}
+	return rate, nil
+}
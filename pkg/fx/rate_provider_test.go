@@ -0,0 +1,54 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aniljaiswalcs/pismo/pkg/money"
+)
+
+func TestStaticRateProvider(t *testing.T) {
+	provider := NewStaticRateProvider(map[string]money.Rate{
+		"usd/brl": money.MustParseRate("4.9512"),
+	})
+
+	rate, err := provider.Rate(context.Background(), "USD", "BRL")
+	if err != nil || rate != money.MustParseRate("4.9512") {
+		t.Errorf("Expected rate 4.9512 but got %s (%v)", rate, err)
+	}
+
+	rate, err = provider.Rate(context.Background(), "BRL", "BRL")
+	if err != nil || rate != money.IdentityRate {
+		t.Errorf("Expected the identity rate but got %s (%v)", rate, err)
+	}
+
+	if _, err = provider.Rate(context.Background(), "BRL", "USD"); !errors.Is(err, ErrRateNotFound) {
+		t.Errorf("Expected %v but got %v", ErrRateNotFound, err)
+	}
+}
+
+func TestLoadRateFile(t *testing.T) {
+	dir := t.TempDir()
+
+	valid := filepath.Join(dir, "rates.json")
+	os.WriteFile(valid, []byte(`{"USD/BRL": 4.9512, "EUR/BRL": "5.3107"}`), 0o600)
+
+	provider, err := LoadRateFile(valid)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	rate, err := provider.Rate(context.Background(), "EUR", "BRL")
+	if err != nil || rate != money.MustParseRate("5.3107") {
+		t.Errorf("Expected rate 5.3107 but got %s (%v)", rate, err)
+	}
+
+	invalid := filepath.Join(dir, "invalid.json")
+	os.WriteFile(invalid, []byte(`{"USDBRL": 4.9512}`), 0o600)
+
+	if _, err := LoadRateFile(invalid); err == nil {
+		t.Errorf("Expected an error for an invalid currency pair")
+	}
+}
@@ -0,0 +1,72 @@
+package lib
+
+// Error codes sent in the code member of problem details. Clients rely on
+// them, so existing codes must never be renamed.
+const (
+	//request
+	CodeUnsupportedMediaType = "unsupported_media_type"
+	CodeBodyTooLarge         = "body_too_large"
+	CodeMalformedBody        = "malformed_body"
+	CodeUnknownField         = "unknown_field"
+	CodeInvalidType          = "invalid_type"
+	CodeValidationFailed     = "validation_failed"
+
+	//account
+	CodeInvalidAccountId      = "invalid_account_id"
+	CodeAccountNotFound       = "account_not_found"
+	CodeInvalidDocumentNumber = "invalid_document_number"
+	CodeDocumentNumberExists  = "document_number_exists"
+
+	//account status
+	CodeInvalidAccountStatus       = "invalid_account_status"
+	CodeInvalidAccountStatusChange = "invalid_account_status_change"
+	CodeOutstandingDebt            = "outstanding_debt"
+	CodeAccountBlocked             = "account_blocked"
+	CodeAccountClosed              = "account_closed"
+
+	//credit limit
+	CodeInvalidCreditLimit      = "invalid_credit_limit"
+	CodeInsufficientCreditLimit = "insufficient_credit_limit"
+
+	//currency
+	CodeInvalidCurrency         = "invalid_currency"
+	CodeCurrencyMismatch        = "currency_mismatch"
+	CodeExchangeRateUnavailable = "exchange_rate_unavailable"
+
+	//transaction
+	CodeInvalidTransactionId = "invalid_transaction_id"
+	CodeTransactionNotFound  = "transaction_not_found"
+	CodeInvalidAmount        = "invalid_amount"
+	CodeInvalidInstallments  = "invalid_installments"
+
+	//transaction history
+	CodeInvalidFilter = "invalid_filter"
+
+	//operation type
+	CodeInvalidOperationType = "invalid_operation_type"
+	CodeOperationTypeExists  = "operation_type_exists"
+
+	//reversal and refund
+	CodeOriginalTransactionRequired      = "original_transaction_required"
+	CodeOriginalTransactionNotAllowed    = "original_transaction_not_allowed"
+	CodeOriginalTransactionNotFound      = "original_transaction_not_found"
+	CodeOriginalTransactionNotRefundable = "original_transaction_not_refundable"
+	CodeRefundExceedsOriginal            = "refund_exceeds_original"
+	CodeReversalNotFull                  = "reversal_not_full"
+
+	//authorization
+	CodeUnauthorized = "unauthorized"
+
+	//idempotency
+	CodeInvalidIdempotencyKey  = "invalid_idempotency_key"
+	CodeIdempotencyKeyReused   = "idempotency_key_reused"
+	CodeIdempotencyKeyInFlight = "idempotency_key_in_flight"
+
+	//database
+	CodeNotFound            = "not_found"
+	CodeConflict            = "conflict"
+	CodeConstraintViolation = "constraint_violation"
+	CodeTimeout             = "timeout"
+	CodeUnavailable         = "unavailable"
+	CodeInternalError       = "internal_error"
+)
@@ -6,7 +6,9 @@ const (
 	StatusServerError    = "Server Error"
 	StatusForbidden      = "Request Forbidden"
 
-	DocumentNumberError = "the document_number must be a valid positive integer"
+	DocumentNumberError       = "the document_number must be a valid CPF or CNPJ"
+	DocumentNumberExistsError = "an account already exists for the document_number"
+	DocumentNumberNotFound    = "no account found for the provided document_number"
 
 	//Acoount
 	AccountCreationError = "an error occurred when creating the account"
@@ -14,15 +16,90 @@ const (
 	AccountIdValidation  = "the account_id must be a valid positive integer"
 	AccountIdNotFound    = "no account found for the provided account ID"
 
+	//account status
+	AccountStatusError           = "the status must be one of the following values: active, blocked, closed"
+	AccountStatusTransitionError = "closed accounts cannot be reopened or blocked"
+	AccountStatusUpdateError     = "an error occurred when updating the account status"
+	OutstandingDebtError         = "the account cannot be closed while it has outstanding debt"
+	AccountBlockedError          = "the account is blocked and only accepts payments and other credits"
+	AccountClosedError           = "the account is closed and accepts no new transactions"
+
+	//credit limit
+	CreditLimitError             = "the available_credit_limit must be a positive decimal or zero"
+	CreditLimitUpdateError       = "an error occurred when updating the credit limit"
+	InsufficientCreditLimitError = "the amount exceeds the available credit limit of the account"
+
+	//currency
+	CurrencyError         = "the currency must be a valid ISO 4217 code"
+	CurrencyMismatchError = "the transaction currency must match the account currency and the currency of its open debts"
+	ExchangeRateError     = "no exchange rate is available to convert the transaction into the account currency"
+	ConvertedAmountError  = "the amount converted into the account currency is larger than an account can hold"
+
+	//transaction
+	ParsingTransactionID    = "error in parsing transactionId"
+	TransactionIdValidation = "the transaction_id must be a valid positive integer"
+	TransactionIdNotFound   = "no transaction found for the provided transaction ID"
+
+	//transaction history
+	OperationTypeFilterError = "the operation_type_id filter must be a valid positive integer"
+	CreatedAtFilterError     = "created_from and created_to must be RFC 3339 timestamps"
+	AmountFilterError        = "min_amount and max_amount must be valid decimals with at most 4 decimal places"
+	OpenOnlyFilterError      = "open_only must be true or false"
+	SortError                = "sort must be either asc or desc"
+	LimitError               = "limit must be an integer between 1 and 200"
+	CursorError              = "the cursor is invalid"
+
 	//opertaion
-	OperationTypeIdError = "the operation_type_id must be one of the following valid values: 1, 2, 3, 4"
-	OperationTypeError   = "purchases and withdraw operations must have a negative amount. Payment operations must have a positive amount."
+	OperationTypeIdError = "the operation_type_id must be one of the following valid values: %s"
+	OperationTypeError   = "purchases and withdraw operations must have a negative amount. Payment, reversal and refund operations must have a positive amount."
 
-	//database
-	DatabaseTimeoutError = "timeout: context deadline exceeded"
-	DatabaseError        = "an error occurred when fetching the account from the database"
-	TimeoutError         = "timeout during operation. Try Again"
+	//operation type administration
+	OperationTypeDefinitionError = "operation types need a positive operation_type_id, a description and a sign of debit or credit; only debits may be dischargeable or consume the credit limit"
+	OperationTypeExistsError     = "an operation type with this operation_type_id already exists"
+	OperationTypeListError       = "an error occurred when fetching the operation types from the database"
+	OperationTypeCreationError   = "an error occurred when creating the operation type"
+
+	//installments
+	InstallmentsError = "installments must be between 0 and 24, 0 meaning one installment, and may only be set on installment purchases"
 
-	//context
-	ContextDeadline = "context deadline exceeded"
+	//reversal and refund
+	OriginalTransactionIdRequired    = "reversal and refund operations must reference an original_transaction_id"
+	OriginalTransactionIdNotAllowed  = "only reversal and refund operations may reference an original_transaction_id"
+	OriginalTransactionNotFound      = "no transaction found for the provided original_transaction_id"
+	OriginalTransactionNotRefundable = "the original transaction must be a purchase or withdrawal of the same account"
+	RefundExceedsOriginalError       = "the amount exceeds what is left to give back on the original transaction"
+	ReversalNotFullError             = "a reversal must give back the whole amount left on the original transaction"
+
+	//authorization
+	UnauthorizedError = "this endpoint requires a valid bearer token in the Authorization header"
+
+	//idempotency
+	IdempotencyKeyError         = "the Idempotency-Key header must have at most 255 characters"
+	IdempotencyKeyReusedError   = "the Idempotency-Key was already used with a different request body"
+	IdempotencyKeyInFlightError = "a request with the same Idempotency-Key is still being processed"
+	IdempotencyKeyReserveError  = "an error occurred when reserving the Idempotency-Key"
+
+	//request
+	ValidationError           = "the request has invalid fields"
+	UnsupportedMediaTypeError = "the request body must be sent as application/json"
+	BodyTooLargeError         = "the request body must be at most 1 MiB"
+	MalformedBodyError        = "the request body must be a single JSON object"
+	UnknownFieldError         = "the field is not accepted by this endpoint"
+	InvalidTypeError          = "the field has the wrong type"
+
+	//health
+	DatabaseUnreachableError = "the database cannot be reached"
+	SchemaVersionError       = "the database schema is not at the migration version this build expects"
+	SchemaDirtyError         = "the last migration failed and left the database schema dirty"
+	PoolExhaustedError       = "every database connection is in use"
+
+	//transaction
+	TransactionCreationError = "an error occurred when creating the transaction"
+
+	//database
+	DatabaseError    = "an error occurred when fetching the account from the database"
+	TimeoutError     = "timeout during operation. Try Again"
+	UnavailableError = "the service is temporarily unavailable. Try Again"
+	ConflictError    = "the request conflicts with the stored data"
+	ConstraintError  = "the request references missing data or breaks a constraint of the stored data"
 )
@@ -0,0 +1,50 @@
+package lib
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+const ProblemContentType = "application/problem+json"
+
+// ProblemTypeBase is joined with the error code to build the type URI of a
+// problem, e.g. /problems/account_not_found.
+const ProblemTypeBase = "/problems/"
+
+// Problem is an RFC 7807 problem details body. Code is a stable identifier
+// clients can switch on; Detail is meant for humans and may change.
+type Problem struct {
+	Type   string       `json:"type"`
+	Title  string       `json:"title"`
+	Status int          `json:"status"`
+	Detail string       `json:"detail,omitempty"`
+	Code   string       `json:"code"`
+	Errors []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError points a validation failure at the request field that caused it.
+type FieldError struct {
+	Field  string `json:"field"`
+	Code   string `json:"code"`
+	Detail string `json:"detail"`
+}
+
+func NewProblem(status int, code string, detail string) Problem {
+	return Problem{
+		Type:   ProblemTypeBase + code,
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+// RenderProblem writes a problem details body. problem is usually a Problem,
+// or a struct embedding one to add extension members.
+func RenderProblem(w http.ResponseWriter, code int, problem interface{}) {
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(problem); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,29 @@
+package lib
+
+import "net/http"
+
+// StatusRecorder forwards a response while keeping its status code. Only the
+// first status written counts, and a body written without one is a 200, as
+// net/http sends it.
+type StatusRecorder struct {
+	http.ResponseWriter
+	StatusCode  int
+	wroteHeader bool
+}
+
+func NewStatusRecorder(w http.ResponseWriter) *StatusRecorder {
+	return &StatusRecorder{ResponseWriter: w, StatusCode: http.StatusOK}
+}
+
+func (r *StatusRecorder) WriteHeader(statusCode int) {
+	if !r.wroteHeader {
+		r.StatusCode = statusCode
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (r *StatusRecorder) Write(data []byte) (int, error) {
+	r.wroteHeader = true
+	return r.ResponseWriter.Write(data)
+}
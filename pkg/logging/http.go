@@ -0,0 +1,71 @@
+package logging
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/aniljaiswalcs/pismo/pkg/lib"
+)
+
+const (
+	RequestIDHeader     = "X-Request-ID"
+	maxRequestIDLength  = 128
+	generatedIDByteSize = 16
+)
+
+// Middleware gives every request an ID, taken from the X-Request-ID header
+// when the client sent a usable one, and generated otherwise. The ID is
+// returned in the X-Request-ID response header and travels in the request
+// context, so every record logged for the request carries it. Each request
+// ends with one access log record; the query string is left out since it
+// may hold document numbers.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		start := time.Now()
+
+		requestID := req.Header.Get(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, requestID)
+
+		ctx := WithRequestID(req.Context(), requestID)
+		recorder := lib.NewStatusRecorder(w)
+
+		next.ServeHTTP(recorder, req.WithContext(ctx))
+
+		slog.InfoContext(ctx, "request completed",
+			"method", req.Method,
+			"path", req.URL.Path,
+			"status", recorder.StatusCode,
+			"duration_ms", time.Since(start).Milliseconds())
+	})
+}
+
+// validRequestID accepts IDs of letters, digits, dashes, underscores and
+// dots, so a client cannot inject arbitrary text into the logs.
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+
+	for _, c := range requestID {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_', c == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	id := make([]byte, generatedIDByteSize)
+	if _, err := rand.Read(id); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(id)
+}
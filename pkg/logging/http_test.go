@@ -0,0 +1,74 @@
+package logging
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aniljaiswalcs/pismo/pkg/config"
+)
+
+func TestMiddlewarePropagatesTheRequestID(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	defaultLogger := slog.Default()
+	slog.SetDefault(New(buffer, config.LOG_INFO))
+	defer slog.SetDefault(defaultLogger)
+
+	var scenarios = []struct {
+		description string
+		header      string
+		kept        bool
+	}{
+		{"Client ID kept", "client-id_1.2", true},
+		{"Missing ID generated", "", false},
+		{"Unsafe ID replaced", "id\nforged log line", false},
+		{"Long ID replaced", strings.Repeat("a", maxRequestIDLength+1), false},
+	}
+
+	for _, scenario := range scenarios {
+		buffer.Reset()
+		var handlerRequestID string
+		handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			handlerRequestID = RequestID(req.Context())
+			slog.InfoContext(req.Context(), "handling")
+			w.WriteHeader(http.StatusCreated)
+		}))
+
+		req, _ := http.NewRequest("GET", "/v1/accounts?document_number=12345678900", nil)
+		if scenario.header != "" {
+			req.Header.Set(RequestIDHeader, scenario.header)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		requestID := w.Header().Get(RequestIDHeader)
+		if scenario.kept && requestID != scenario.header {
+			t.Errorf("%s: expected %q but got %q", scenario.description, scenario.header, requestID)
+		}
+		if !scenario.kept && (requestID == scenario.header || !validRequestID(requestID)) {
+			t.Errorf("%s: expected a generated ID but got %q", scenario.description, requestID)
+		}
+		if handlerRequestID != requestID {
+			t.Errorf("%s: expected the handler to see %q but got %q", scenario.description, requestID, handlerRequestID)
+		}
+
+		logged := records(t, buffer)
+		if len(logged) != 2 {
+			t.Fatalf("%s: expected 2 records but got %d", scenario.description, len(logged))
+		}
+		for _, record := range logged {
+			if record[RequestIDKey] != requestID {
+				t.Errorf("%s: expected %q in %v", scenario.description, requestID, record)
+			}
+		}
+		if logged[1]["status"] != float64(http.StatusCreated) || logged[1]["path"] != "/v1/accounts" {
+			t.Errorf("%s: expected the access record but got %v", scenario.description, logged[1])
+		}
+		if strings.Contains(buffer.String(), "12345678900") {
+			t.Errorf("%s: expected the query string to stay out of the logs", scenario.description)
+		}
+	}
+}
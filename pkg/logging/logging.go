@@ -0,0 +1,90 @@
+// Package logging sets up the structured JSON logs of the API. Records
+// logged with a request context carry the request ID, and document numbers
+// are redacted wherever they are logged.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/aniljaiswalcs/pismo/pkg/config"
+)
+
+const (
+	RequestIDKey      = "request_id"
+	DocumentNumberKey = "document_number"
+)
+
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx carrying the request ID.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestID returns the request ID carried by ctx, or "" outside a request.
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// New returns a logger writing JSON records of at least the given level, one
+// of the config.LOG_* levels, to w.
+func New(w io.Writer, level string) *slog.Logger {
+	handler := slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level:       ParseLevel(level),
+		ReplaceAttr: redact,
+	})
+	return slog.New(contextHandler{handler})
+}
+
+func ParseLevel(level string) slog.Level {
+	switch level {
+	case config.LOG_DEBUG:
+		return slog.LevelDebug
+	case config.LOG_WARN:
+		return slog.LevelWarn
+	case config.LOG_ERROR:
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// RedactDocument masks a document number but for its last two characters,
+// enough to tell documents apart when troubleshooting.
+func RedactDocument(documentNumber string) string {
+	if len(documentNumber) <= 4 {
+		return strings.Repeat("*", len(documentNumber))
+	}
+	return strings.Repeat("*", len(documentNumber)-2) + documentNumber[len(documentNumber)-2:]
+}
+
+func redact(groups []string, attr slog.Attr) slog.Attr {
+	if attr.Key == DocumentNumberKey {
+		return slog.String(attr.Key, RedactDocument(attr.Value.String()))
+	}
+	return attr
+}
+
+// contextHandler adds the request ID of the context to every record.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if requestID := RequestID(ctx); requestID != "" {
+		record.AddAttrs(slog.String(RequestIDKey, requestID))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
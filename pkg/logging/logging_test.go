@@ -0,0 +1,90 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/aniljaiswalcs/pismo/pkg/config"
+)
+
+// records decodes the JSON records written to buffer.
+func records(t *testing.T, buffer *bytes.Buffer) []map[string]interface{} {
+	result := []map[string]interface{}{}
+	for _, line := range strings.Split(strings.TrimSpace(buffer.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		record := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("Expected a JSON record but got %q", line)
+		}
+		result = append(result, record)
+	}
+	return result
+}
+
+func TestLoggerAddsTheRequestIDOfTheContext(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	logger := New(buffer, config.LOG_INFO)
+
+	logger.InfoContext(WithRequestID(context.Background(), "abc-123"), "inside a request")
+	logger.InfoContext(context.Background(), "outside a request")
+
+	logged := records(t, buffer)
+	if len(logged) != 2 {
+		t.Fatalf("Expected 2 records but got %d", len(logged))
+	}
+	if logged[0][RequestIDKey] != "abc-123" {
+		t.Errorf("Expected the request ID but got %v", logged[0][RequestIDKey])
+	}
+	if _, found := logged[1][RequestIDKey]; found {
+		t.Errorf("Expected no request ID outside a request but got %v", logged[1][RequestIDKey])
+	}
+}
+
+func TestLoggerRedactsDocumentNumbers(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	logger := New(buffer, config.LOG_INFO)
+
+	logger.With("account", "1").Error("query failed", DocumentNumberKey, "12345678900")
+
+	if strings.Contains(buffer.String(), "12345678900") {
+		t.Errorf("Expected the document number to be redacted but got %s", buffer.String())
+	}
+	if logged := records(t, buffer); logged[0][DocumentNumberKey] != "*********00" {
+		t.Errorf("Expected the redacted document number but got %v", logged[0][DocumentNumberKey])
+	}
+}
+
+func TestLoggerLevel(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	logger := New(buffer, config.LOG_WARN)
+
+	logger.Info("dropped")
+	logger.Warn("kept")
+
+	if logged := records(t, buffer); len(logged) != 1 || logged[0]["msg"] != "kept" {
+		t.Errorf("Expected only the warning but got %v", logged)
+	}
+}
+
+func TestRedactDocument(t *testing.T) {
+	var scenarios = []struct {
+		documentNumber string
+		expected       string
+	}{
+		{"12345678900", "*********00"},
+		{"12345678000190", "************90"},
+		{"1234", "****"},
+		{"", ""},
+	}
+
+	for _, scenario := range scenarios {
+		if redacted := RedactDocument(scenario.documentNumber); redacted != scenario.expected {
+			t.Errorf("Expected %q for %q but got %q", scenario.expected, scenario.documentNumber, redacted)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/aniljaiswalcs/pismo/pkg/lib"
+)
+
+// Middleware counts and times the requests matched by a gorilla/mux router.
+// Requests are labelled with the route template, such as
+// /v1/accounts/{accountId:[0-9]+}, so ids never become label values.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		start := time.Now()
+		recorder := lib.NewStatusRecorder(w)
+
+		next.ServeHTTP(recorder, req)
+
+		route := "unknown"
+		if current := mux.CurrentRoute(req); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				route = template
+			}
+		}
+
+		status := strconv.Itoa(recorder.StatusCode)
+		httpRequests.WithLabelValues(req.Method, route, status).Inc()
+		httpRequestDuration.WithLabelValues(req.Method, route, status).Observe(time.Since(start).Seconds())
+	})
+}
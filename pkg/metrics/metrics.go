@@ -0,0 +1,138 @@
+// Package metrics exposes the Prometheus metrics of the API: HTTP requests
+// per route, repository call latency, the database pool and business events.
+// Every metric is registered on Registry, which Handler serves.
+package metrics
+
+import (
+	"database/sql"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/aniljaiswalcs/pismo/pkg/apperror"
+	"github.com/aniljaiswalcs/pismo/pkg/money"
+)
+
+const namespace = "pismo"
+
+// Outcomes of a repository call, from the apperror kind of its error.
+const (
+	OUTCOME_OK          = "ok"
+	OUTCOME_NOT_FOUND   = "not_found"
+	OUTCOME_CONFLICT    = "conflict"
+	OUTCOME_VALIDATION  = "validation"
+	OUTCOME_TIMEOUT     = "timeout"
+	OUTCOME_UNAVAILABLE = "unavailable"
+	OUTCOME_ERROR       = "error"
+)
+
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route template and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Time to serve HTTP requests by method, route template and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	repositoryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "repository_call_duration_seconds",
+		Help:      "Time spent in repository calls by repository, method and outcome.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+	}, []string{"repository", "method", "outcome"})
+
+	transactionsCreated = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "transactions_created_total",
+		Help:      "Transactions created by operation type.",
+	}, []string{"operation_type"})
+
+	dischargedAmount = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "discharged_amount_total",
+		Help:      "Amount of payments, reversals and refunds used to settle debts, by currency.",
+	}, []string{"currency"})
+
+	dischargedDebts = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "discharged_debts_total",
+		Help:      "Debt rows whose balance was touched by a discharge.",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpRequestDuration,
+		repositoryDuration,
+		transactionsCreated,
+		dischargedAmount,
+		dischargedDebts,
+	)
+}
+
+// Handler serves the metrics in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// RegisterDB exposes the statistics of the connection pool of db, such as
+// connections in use and time spent waiting for one.
+func RegisterDB(db *sql.DB, name string) {
+	Registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// ObserveRepositoryCall records how long a repository call took since start
+// and how it ended.
+func ObserveRepositoryCall(repository string, method string, start time.Time, err error) {
+	repositoryDuration.WithLabelValues(repository, method, outcome(err)).Observe(time.Since(start).Seconds())
+}
+
+func outcome(err error) string {
+	switch {
+	case err == nil:
+		return OUTCOME_OK
+	case errors.Is(err, apperror.ErrNotFound):
+		return OUTCOME_NOT_FOUND
+	case errors.Is(err, apperror.ErrConflict):
+		return OUTCOME_CONFLICT
+	case errors.Is(err, apperror.ErrValidation):
+		return OUTCOME_VALIDATION
+	case errors.Is(err, apperror.ErrTimeout):
+		return OUTCOME_TIMEOUT
+	case errors.Is(err, apperror.ErrUnavailable):
+		return OUTCOME_UNAVAILABLE
+	default:
+		return OUTCOME_ERROR
+	}
+}
+
+// TransactionCreated counts a stored transaction.
+func TransactionCreated(operationTypeId uint32) {
+	transactionsCreated.WithLabelValues(strconv.FormatUint(uint64(operationTypeId), 10)).Inc()
+}
+
+// Discharged counts the amount a payment, reversal or refund settled and the
+// debts it touched. Call it once the database transaction is committed.
+func Discharged(currency string, amount money.Amount, debts int) {
+	if amount.IsPositive() {
+		dischargedAmount.WithLabelValues(currency).Add(float64(amount.Units()) / math.Pow10(money.Scale))
+	}
+	dischargedDebts.Add(float64(debts))
+}
@@ -0,0 +1,108 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/aniljaiswalcs/pismo/model"
+	"github.com/aniljaiswalcs/pismo/pkg/apperror"
+	"github.com/aniljaiswalcs/pismo/pkg/money"
+	"github.com/aniljaiswalcs/pismo/repository"
+)
+
+func TestMiddlewareLabelsRequestsWithTheRouteTemplate(t *testing.T) {
+	router := mux.NewRouter()
+	router.Use(Middleware)
+	accounts := router.PathPrefix("/v1/accounts").Subrouter()
+	accounts.HandleFunc("/{accountId:[0-9]+}", func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}).Methods("GET")
+
+	route := "/v1/accounts/{accountId:[0-9]+}"
+	before := testutil.ToFloat64(httpRequests.WithLabelValues("GET", route, "404"))
+
+	for _, accountId := range []int{1, 2} {
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/v1/accounts/%d", accountId), nil)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	if counted := testutil.ToFloat64(httpRequests.WithLabelValues("GET", route, "404")) - before; counted != 2 {
+		t.Errorf("Expected 2 requests counted on %s but got %v", route, counted)
+	}
+}
+
+func TestOutcome(t *testing.T) {
+	var scenarios = []struct {
+		err      error
+		expected string
+	}{
+		{nil, OUTCOME_OK},
+		{apperror.New(apperror.ErrNotFound, "no account"), OUTCOME_NOT_FOUND},
+		{apperror.New(apperror.ErrConflict, "duplicate key"), OUTCOME_CONFLICT},
+		{apperror.New(apperror.ErrValidation, "foreign key violation"), OUTCOME_VALIDATION},
+		{apperror.Wrap(apperror.ErrTimeout, context.DeadlineExceeded), OUTCOME_TIMEOUT},
+		{apperror.New(apperror.ErrUnavailable, "connection refused"), OUTCOME_UNAVAILABLE},
+		{errors.New("Error!"), OUTCOME_ERROR},
+	}
+
+	for _, scenario := range scenarios {
+		if got := outcome(scenario.err); got != scenario.expected {
+			t.Errorf("Expected %q for %v but got %q", scenario.expected, scenario.err, got)
+		}
+	}
+}
+
+type stubOperationTypeRepository struct {
+	repository.OperationTypeRepository
+	err error
+}
+
+func (r *stubOperationTypeRepository) ListOperationTypes(ctx context.Context) ([]model.OperationType, error) {
+	return nil, r.err
+}
+
+func TestInstrumentedRepositoryObservesEveryCall(t *testing.T) {
+	instrumented := InstrumentOperationTypeRepository(&stubOperationTypeRepository{err: apperror.New(apperror.ErrUnavailable, "connection refused")})
+
+	before := testutil.CollectAndCount(repositoryDuration)
+	_, err := instrumented.ListOperationTypes(context.Background())
+
+	if !errors.Is(err, apperror.ErrUnavailable) {
+		t.Errorf("Expected the error of the repository but got %v", err)
+	}
+	if testutil.CollectAndCount(repositoryDuration) != before+1 {
+		t.Errorf("Expected the call to be observed")
+	}
+}
+
+func TestDischarged(t *testing.T) {
+	amount := testutil.ToFloat64(dischargedAmount.WithLabelValues("BRL"))
+	debts := testutil.ToFloat64(dischargedDebts)
+
+	Discharged("BRL", money.MustParse("60.5"), 2)
+	Discharged("BRL", money.MustParse("0"), 0)
+
+	if added := testutil.ToFloat64(dischargedAmount.WithLabelValues("BRL")) - amount; added != 60.5 {
+		t.Errorf("Expected 60.5 discharged but got %v", added)
+	}
+	if added := testutil.ToFloat64(dischargedDebts) - debts; added != 2 {
+		t.Errorf("Expected 2 debts discharged but got %v", added)
+	}
+}
+
+func TestTransactionCreated(t *testing.T) {
+	before := testutil.ToFloat64(transactionsCreated.WithLabelValues("4"))
+
+	TransactionCreated(model.PAYMENT)
+
+	if added := testutil.ToFloat64(transactionsCreated.WithLabelValues("4")) - before; added != 1 {
+		t.Errorf("Expected 1 payment counted but got %v", added)
+	}
+}
@@ -0,0 +1,164 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/aniljaiswalcs/pismo/model"
+	"github.com/aniljaiswalcs/pismo/pkg/money"
+	"github.com/aniljaiswalcs/pismo/repository"
+)
+
+// The repository wrappers time every call of the repository they wrap with
+// ObserveRepositoryCall, so the adapters stay free of metrics code.
+
+type accountRepository struct {
+	next repository.AccountRepository
+}
+
+func InstrumentAccountRepository(next repository.AccountRepository) repository.AccountRepository {
+	return &accountRepository{next: next}
+}
+
+func (r *accountRepository) CreateAccount(ctx context.Context, account model.Account) (*model.Account, error) {
+	start := time.Now()
+	created, err := r.next.CreateAccount(ctx, account)
+	ObserveRepositoryCall("account", "CreateAccount", start, err)
+	return created, err
+}
+
+func (r *accountRepository) FindAccount(ctx context.Context, accountId uint64) (*model.Account, error) {
+	start := time.Now()
+	account, err := r.next.FindAccount(ctx, accountId)
+	ObserveRepositoryCall("account", "FindAccount", start, err)
+	return account, err
+}
+
+func (r *accountRepository) FindAccountByDocument(ctx context.Context, documentNumber string) (*model.Account, error) {
+	start := time.Now()
+	account, err := r.next.FindAccountByDocument(ctx, documentNumber)
+	ObserveRepositoryCall("account", "FindAccountByDocument", start, err)
+	return account, err
+}
+
+func (r *accountRepository) UpdateCreditLimit(ctx context.Context, accountId uint64, limit money.Amount, reason string) (*model.Account, error) {
+	start := time.Now()
+	account, err := r.next.UpdateCreditLimit(ctx, accountId, limit, reason)
+	ObserveRepositoryCall("account", "UpdateCreditLimit", start, err)
+	return account, err
+}
+
+func (r *accountRepository) UpdateStatus(ctx context.Context, accountId uint64, status string) (*model.Account, error) {
+	start := time.Now()
+	account, err := r.next.UpdateStatus(ctx, accountId, status)
+	ObserveRepositoryCall("account", "UpdateStatus", start, err)
+	return account, err
+}
+
+type transactionRepository struct {
+	next repository.TransactionRepository
+}
+
+func InstrumentTransactionRepository(next repository.TransactionRepository) repository.TransactionRepository {
+	return &transactionRepository{next: next}
+}
+
+func (r *transactionRepository) CreateTransaction(ctx context.Context, transaction model.Transaction) (*model.Transaction, error) {
+	start := time.Now()
+	created, err := r.next.CreateTransaction(ctx, transaction)
+	ObserveRepositoryCall("transaction", "CreateTransaction", start, err)
+	return created, err
+}
+
+func (r *transactionRepository) SubtractTransaction(ctx context.Context, transaction model.Transaction) error {
+	start := time.Now()
+	err := r.next.SubtractTransaction(ctx, transaction)
+	ObserveRepositoryCall("transaction", "SubtractTransaction", start, err)
+	return err
+}
+
+func (r *transactionRepository) FindtransactionAccount(ctx context.Context, transactionId uint64) (*model.Transaction, error) {
+	start := time.Now()
+	transaction, err := r.next.FindtransactionAccount(ctx, transactionId)
+	ObserveRepositoryCall("transaction", "FindtransactionAccount", start, err)
+	return transaction, err
+}
+
+func (r *transactionRepository) FindPaymentAllocations(ctx context.Context, transactionId uint64) ([]model.PaymentAllocation, error) {
+	start := time.Now()
+	allocations, err := r.next.FindPaymentAllocations(ctx, transactionId)
+	ObserveRepositoryCall("transaction", "FindPaymentAllocations", start, err)
+	return allocations, err
+}
+
+func (r *transactionRepository) ListTransactions(ctx context.Context, accountId uint64, filter model.TransactionFilter) (*model.TransactionPage, error) {
+	start := time.Now()
+	page, err := r.next.ListTransactions(ctx, accountId, filter)
+	ObserveRepositoryCall("transaction", "ListTransactions", start, err)
+	return page, err
+}
+
+func (r *transactionRepository) GetAccountBalance(ctx context.Context, accountId uint64) (*model.AccountBalance, error) {
+	start := time.Now()
+	balance, err := r.next.GetAccountBalance(ctx, accountId)
+	ObserveRepositoryCall("transaction", "GetAccountBalance", start, err)
+	return balance, err
+}
+
+type idempotencyRepository struct {
+	next repository.IdempotencyRepository
+}
+
+func InstrumentIdempotencyRepository(next repository.IdempotencyRepository) repository.IdempotencyRepository {
+	return &idempotencyRepository{next: next}
+}
+
+func (r *idempotencyRepository) ReserveKey(ctx context.Context, record model.IdempotencyRecord) (*model.IdempotencyRecord, bool, error) {
+	start := time.Now()
+	existing, reserved, err := r.next.ReserveKey(ctx, record)
+	ObserveRepositoryCall("idempotency", "ReserveKey", start, err)
+	return existing, reserved, err
+}
+
+func (r *idempotencyRepository) CompleteKey(ctx context.Context, record model.IdempotencyRecord) error {
+	start := time.Now()
+	err := r.next.CompleteKey(ctx, record)
+	ObserveRepositoryCall("idempotency", "CompleteKey", start, err)
+	return err
+}
+
+func (r *idempotencyRepository) ReleaseKey(ctx context.Context, scope string, key string) error {
+	start := time.Now()
+	err := r.next.ReleaseKey(ctx, scope, key)
+	ObserveRepositoryCall("idempotency", "ReleaseKey", start, err)
+	return err
+}
+
+func (r *idempotencyRepository) DeleteExpiredKeys(ctx context.Context, before time.Time) (int64, error) {
+	start := time.Now()
+	deleted, err := r.next.DeleteExpiredKeys(ctx, before)
+	ObserveRepositoryCall("idempotency", "DeleteExpiredKeys", start, err)
+	return deleted, err
+}
+
+type operationTypeRepository struct {
+	next repository.OperationTypeRepository
+}
+
+func InstrumentOperationTypeRepository(next repository.OperationTypeRepository) repository.OperationTypeRepository {
+	return &operationTypeRepository{next: next}
+}
+
+func (r *operationTypeRepository) ListOperationTypes(ctx context.Context) ([]model.OperationType, error) {
+	start := time.Now()
+	operationTypes, err := r.next.ListOperationTypes(ctx)
+	ObserveRepositoryCall("operation_type", "ListOperationTypes", start, err)
+	return operationTypes, err
+}
+
+func (r *operationTypeRepository) CreateOperationType(ctx context.Context, operationType model.OperationType) (*model.OperationType, error) {
+	start := time.Now()
+	created, err := r.next.CreateOperationType(ctx, operationType)
+	ObserveRepositoryCall("operation_type", "CreateOperationType", start, err)
+	return created, err
+}
@@ -0,0 +1,224 @@
+// Package money implements the fixed-point amount used for every monetary
+// value of the API.
+//
+// An Amount is an integer number of ten-thousandths, the same scale as the
+// NUMERIC(12, 4) columns of the database, so values are stored and sent back
+// exactly as received.
+//
+// Rounding policy: amounts coming from clients or from the database must be
+// representable with at most four decimal places and are rejected otherwise;
+// they are never rounded silently. Amounts converted at an exchange rate are
+// rounded half to even at the fourth decimal place. Totals divided with Split
+// are not rounded: every part is truncated and the last part takes the
+// remainder, so the parts always add up to the total.
+package money
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Scale is the number of decimal places kept by an Amount.
+const Scale = 4
+
+const unit = 10000
+
+// MaxAbs bounds the absolute value of an Amount to what a NUMERIC(12, 4)
+// column can hold.
+const MaxAbs = Amount(100000000*unit - 1)
+
+var (
+	ErrInvalidAmount = errors.New("money: invalid amount")
+	ErrPrecision     = errors.New("money: amount has more than 4 decimal places")
+	ErrOutOfRange    = errors.New("money: amount out of range")
+)
+
+type Amount int64
+
+// FromUnits returns the amount holding the given number of ten-thousandths.
+func FromUnits(units int64) Amount {
+	return Amount(units)
+}
+
+// Parse reads a plain decimal string such as "-123.45". Exponents are not
+// accepted.
+func Parse(s string) (Amount, error) {
+	value := strings.TrimSpace(s)
+
+	negative := false
+	if strings.HasPrefix(value, "-") {
+		negative = true
+		value = value[1:]
+	} else if strings.HasPrefix(value, "+") {
+		value = value[1:]
+	}
+
+	integer, fraction, hasPoint := strings.Cut(value, ".")
+	if !isDigits(integer) || (hasPoint && !isDigits(fraction)) {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+
+	fraction = strings.TrimRight(fraction, "0")
+	if len(fraction) > Scale {
+		return 0, fmt.Errorf("%w: %q", ErrPrecision, s)
+	}
+
+	integer = strings.TrimLeft(integer, "0")
+	if len(integer) > 8 {
+		return 0, fmt.Errorf("%w: %q", ErrOutOfRange, s)
+	}
+
+	units, _ := strconv.ParseInt("0"+integer+fraction+strings.Repeat("0", Scale-len(fraction)), 10, 64)
+	if negative {
+		units = -units
+	}
+
+	return Amount(units), nil
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// MustParse is like Parse but panics on invalid input. It is meant for
+// constants and tests.
+func MustParse(s string) Amount {
+	a, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+func (a Amount) Units() int64 {
+	return int64(a)
+}
+
+func (a Amount) Add(b Amount) Amount {
+	return a + b
+}
+
+func (a Amount) Sub(b Amount) Amount {
+	return a - b
+}
+
+func (a Amount) Neg() Amount {
+	return -a
+}
+
+func (a Amount) Abs() Amount {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
+
+// Split divides the amount into n parts that add up exactly to it. Every part
+// gets the quotient truncated to four decimal places and the last part also
+// takes the remainder, so no ten-thousandth is lost or created.
+func (a Amount) Split(n int) []Amount {
+	if n <= 0 {
+		return nil
+	}
+
+	part := a / Amount(n)
+	parts := make([]Amount, n)
+	for i := range parts {
+		parts[i] = part
+	}
+	parts[n-1] = a - part*Amount(n-1)
+
+	return parts
+}
+
+func (a Amount) IsZero() bool {
+	return a == 0
+}
+
+func (a Amount) IsNegative() bool {
+	return a < 0
+}
+
+func (a Amount) IsPositive() bool {
+	return a > 0
+}
+
+// String formats the amount without trailing zeros, e.g. "-123.45" or "100".
+func (a Amount) String() string {
+	sign := ""
+	units := int64(a)
+	if units < 0 {
+		sign = "-"
+		units = -units
+	}
+
+	integer := strconv.FormatInt(units/unit, 10)
+	fraction := strings.TrimRight(fmt.Sprintf("%04d", units%unit), "0")
+	if fraction == "" {
+		return sign + integer
+	}
+	return sign + integer + "." + fraction
+}
+
+// MarshalJSON encodes the amount as a JSON number.
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalJSON accepts a JSON number or a string holding one.
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+
+	parsed, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+// Scan implements sql.Scanner for NUMERIC columns.
+func (a *Amount) Scan(src interface{}) error {
+	var s string
+	switch v := src.(type) {
+	case []byte:
+		s = string(v)
+	case string:
+		s = v
+	case int64:
+		s = strconv.FormatInt(v, 10)
+	case float64:
+		s = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Errorf("%w: cannot scan %T", ErrInvalidAmount, src)
+	}
+
+	parsed, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+// Value implements driver.Valuer, sending the amount as an exact decimal.
+func (a Amount) Value() (driver.Value, error) {
+	return a.String(), nil
+}
@@ -0,0 +1,151 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	var scenarios = []struct {
+		input         string
+		expectedUnits int64
+		expectedError error
+	}{
+		{"123.45", 1234500, nil},
+		{"-123.45", -1234500, nil},
+		{"+0.0001", 1, nil},
+		{"100", 1000000, nil},
+		{"100.0", 1000000, nil},
+		{"0.12340", 1234, nil},
+		{"99999999.9999", 999999999999, nil},
+		{"0.00001", 0, ErrPrecision},
+		{"100000000", 0, ErrOutOfRange},
+		{"1e2", 0, ErrInvalidAmount},
+		{"1/2", 0, ErrInvalidAmount},
+		{"1.", 0, ErrInvalidAmount},
+		{".5", 0, ErrInvalidAmount},
+		{"", 0, ErrInvalidAmount},
+		{"abc", 0, ErrInvalidAmount},
+	}
+
+	for _, scenario := range scenarios {
+		amount, err := Parse(scenario.input)
+
+		if !errors.Is(err, scenario.expectedError) {
+			t.Errorf("Expected error %v for %q but got %v", scenario.expectedError, scenario.input, err)
+			continue
+		}
+		if amount.Units() != scenario.expectedUnits {
+			t.Errorf("Expected %d units for %q but got %d", scenario.expectedUnits, scenario.input, amount.Units())
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	var scenarios = []struct {
+		amount   Amount
+		expected string
+	}{
+		{FromUnits(1234500), "123.45"},
+		{FromUnits(-1234500), "-123.45"},
+		{FromUnits(1000000), "100"},
+		{FromUnits(1), "0.0001"},
+		{FromUnits(-1), "-0.0001"},
+		{FromUnits(0), "0"},
+	}
+
+	for _, scenario := range scenarios {
+		if scenario.amount.String() != scenario.expected {
+			t.Errorf("Expected %s but got %s", scenario.expected, scenario.amount.String())
+		}
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	type payload struct {
+		Amount Amount `json:"amount"`
+	}
+
+	for _, input := range []string{`{"amount":123.45}`, `{"amount":-0.1}`, `{"amount":18.7}`, `{"amount":0}`} {
+		var decoded payload
+		if err := json.Unmarshal([]byte(input), &decoded); err != nil {
+			t.Errorf("Unexpected error decoding %s: %s", input, err)
+			continue
+		}
+
+		encoded, _ := json.Marshal(decoded)
+		if string(encoded) != input {
+			t.Errorf("Expected %s but got %s", input, encoded)
+		}
+	}
+
+	var decoded payload
+	if err := json.Unmarshal([]byte(`{"amount":"50.5"}`), &decoded); err != nil || decoded.Amount != MustParse("50.5") {
+		t.Errorf("Expected quoted amounts to be accepted, got %s (%v)", decoded.Amount, err)
+	}
+	if err := json.Unmarshal([]byte(`{"amount":1.00001}`), &decoded); !errors.Is(err, ErrPrecision) {
+		t.Errorf("Expected a precision error but got %v", err)
+	}
+}
+
+func TestScan(t *testing.T) {
+	var scenarios = []struct {
+		src      interface{}
+		expected Amount
+	}{
+		{[]byte("123.4500"), MustParse("123.45")},
+		{"-18.7000", MustParse("-18.7")},
+		{int64(10), MustParse("10")},
+		{float64(0.5), MustParse("0.5")},
+	}
+
+	for _, scenario := range scenarios {
+		var amount Amount
+		if err := amount.Scan(scenario.src); err != nil {
+			t.Errorf("Unexpected error scanning %v: %s", scenario.src, err)
+			continue
+		}
+		if amount != scenario.expected {
+			t.Errorf("Expected %s but got %s", scenario.expected, amount)
+		}
+	}
+
+	var amount Amount
+	if err := amount.Scan(nil); err == nil {
+		t.Errorf("Expected an error scanning NULL")
+	}
+}
+
+func TestSplit(t *testing.T) {
+	var scenarios = []struct {
+		amount   Amount
+		parts    int
+		expected []Amount
+	}{
+		{MustParse("100"), 4, []Amount{MustParse("25"), MustParse("25"), MustParse("25"), MustParse("25")}},
+		{MustParse("100"), 3, []Amount{MustParse("33.3333"), MustParse("33.3333"), MustParse("33.3334")}},
+		{MustParse("-100"), 3, []Amount{MustParse("-33.3333"), MustParse("-33.3333"), MustParse("-33.3334")}},
+		{MustParse("0.0002"), 3, []Amount{MustParse("0"), MustParse("0"), MustParse("0.0002")}},
+		{MustParse("10"), 1, []Amount{MustParse("10")}},
+	}
+
+	for _, scenario := range scenarios {
+		parts := scenario.amount.Split(scenario.parts)
+
+		if len(parts) != len(scenario.expected) {
+			t.Errorf("Expected %v splitting %s but got %v", scenario.expected, scenario.amount, parts)
+			continue
+		}
+		for i := range parts {
+			if parts[i] != scenario.expected[i] {
+				t.Errorf("Expected %v splitting %s but got %v", scenario.expected, scenario.amount, parts)
+				break
+			}
+		}
+	}
+
+	if parts := MustParse("10").Split(0); parts != nil {
+		t.Errorf("Expected no parts but got %v", parts)
+	}
+}
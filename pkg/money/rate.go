@@ -0,0 +1,139 @@
+package money
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// RateScale is the number of decimal places kept by a Rate.
+const RateScale = 8
+
+const rateUnit = 100000000
+
+// Rate is an exchange rate stored as an integer number of 10^-8 units,
+// matching the NUMERIC(18, 8) exchange_rate column.
+type Rate int64
+
+// ParseRate reads a positive plain decimal string such as "5.12345678".
+func ParseRate(s string) (Rate, error) {
+	value := strings.TrimSpace(s)
+
+	integer, fraction, hasPoint := strings.Cut(value, ".")
+	if !isDigits(integer) || (hasPoint && !isDigits(fraction)) {
+		return 0, fmt.Errorf("%w: rate %q", ErrInvalidAmount, s)
+	}
+
+	fraction = strings.TrimRight(fraction, "0")
+	if len(fraction) > RateScale {
+		return 0, fmt.Errorf("%w: rate %q", ErrPrecision, s)
+	}
+
+	integer = strings.TrimLeft(integer, "0")
+	if len(integer) > 10 {
+		return 0, fmt.Errorf("%w: rate %q", ErrOutOfRange, s)
+	}
+
+	units, _ := strconv.ParseInt("0"+integer+fraction+strings.Repeat("0", RateScale-len(fraction)), 10, 64)
+	if units == 0 {
+		return 0, fmt.Errorf("%w: rate %q must be positive", ErrInvalidAmount, s)
+	}
+
+	return Rate(units), nil
+}
+
+// MustParseRate is like ParseRate but panics on invalid input.
+func MustParseRate(s string) Rate {
+	r, err := ParseRate(s)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// IdentityRate converts an amount to itself.
+const IdentityRate = Rate(rateUnit)
+
+func (r Rate) String() string {
+	integer := strconv.FormatInt(int64(r)/rateUnit, 10)
+	fraction := strings.TrimRight(fmt.Sprintf("%08d", int64(r)%rateUnit), "0")
+	if fraction == "" {
+		return integer
+	}
+	return integer + "." + fraction
+}
+
+func (r Rate) MarshalJSON() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// UnmarshalJSON accepts a JSON number or a string holding one.
+func (r *Rate) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+
+	parsed, err := ParseRate(s)
+	if err != nil {
+		return err
+	}
+	*r = parsed
+	return nil
+}
+
+func (r *Rate) Scan(src interface{}) error {
+	var s string
+	switch v := src.(type) {
+	case []byte:
+		s = string(v)
+	case string:
+		s = v
+	default:
+		return fmt.Errorf("%w: cannot scan %T into a rate", ErrInvalidAmount, src)
+	}
+
+	parsed, err := ParseRate(s)
+	if err != nil {
+		return err
+	}
+	*r = parsed
+	return nil
+}
+
+func (r Rate) Value() (driver.Value, error) {
+	return r.String(), nil
+}
+
+// Convert multiplies the amount by the rate, rounding half to even at the
+// fourth decimal place.
+func (a Amount) Convert(r Rate) (Amount, error) {
+	product := new(big.Int).Mul(big.NewInt(int64(a)), big.NewInt(int64(r)))
+	units := roundHalfEven(product, big.NewInt(rateUnit))
+
+	if !units.IsInt64() || units.Int64() > int64(MaxAbs) || units.Int64() < -int64(MaxAbs) {
+		return 0, fmt.Errorf("%w: %s converted at %s", ErrOutOfRange, a, r)
+	}
+	return Amount(units.Int64()), nil
+}
+
+func roundHalfEven(numerator, denominator *big.Int) *big.Int {
+	quotient, remainder := new(big.Int).QuoRem(numerator, denominator, new(big.Int))
+
+	twice := new(big.Int).Abs(remainder)
+	twice.Lsh(twice, 1)
+	switch twice.Cmp(denominator) {
+	case 1:
+		quotient.Add(quotient, big.NewInt(int64(numerator.Sign())))
+	case 0:
+		if quotient.Bit(0) == 1 {
+			quotient.Add(quotient, big.NewInt(int64(numerator.Sign())))
+		}
+	}
+	return quotient
+}
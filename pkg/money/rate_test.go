@@ -0,0 +1,63 @@
+package money
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseRate(t *testing.T) {
+	var scenarios = []struct {
+		input         string
+		expected      string
+		expectedError error
+	}{
+		{"5.12345678", "5.12345678", nil},
+		{"1", "1", nil},
+		{"0.5000", "0.5", nil},
+		{"0", "", ErrInvalidAmount},
+		{"-1", "", ErrInvalidAmount},
+		{"1.123456789", "", ErrPrecision},
+	}
+
+	for _, scenario := range scenarios {
+		rate, err := ParseRate(scenario.input)
+
+		if !errors.Is(err, scenario.expectedError) {
+			t.Errorf("Expected error %v for %q but got %v", scenario.expectedError, scenario.input, err)
+			continue
+		}
+		if err == nil && rate.String() != scenario.expected {
+			t.Errorf("Expected %s for %q but got %s", scenario.expected, scenario.input, rate)
+		}
+	}
+}
+
+func TestConvert(t *testing.T) {
+	var scenarios = []struct {
+		amount   string
+		rate     string
+		expected string
+	}{
+		{"-100", "4.9512", "-495.12"},
+		{"10", "0.33333333", "3.3333"},
+		{"-0.0001", "0.5", "0"},
+		{"0.0003", "0.5", "0.0002"},
+		{"0.0001", "1.5", "0.0002"},
+		{"123.45", "1", "123.45"},
+	}
+
+	for _, scenario := range scenarios {
+		converted, err := MustParse(scenario.amount).Convert(MustParseRate(scenario.rate))
+		if err != nil {
+			t.Errorf("Unexpected error converting %s at %s: %s", scenario.amount, scenario.rate, err)
+			continue
+		}
+		if converted != MustParse(scenario.expected) {
+			t.Errorf("Expected %s converting %s at %s but got %s", scenario.expected, scenario.amount, scenario.rate, converted)
+		}
+	}
+
+	if _, err := MustParse("99999999").Convert(MustParseRate("2")); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("Expected an out of range error but got %v", err)
+	}
+}
@@ -4,9 +4,13 @@ import (
 	"context"
 
 	"github.com/aniljaiswalcs/pismo/model"
+	"github.com/aniljaiswalcs/pismo/pkg/money"
 )
 
 type AccountRepository interface {
 	CreateAccount(ctx context.Context, account model.Account) (*model.Account, error)
 	FindAccount(ctx context.Context, accountId uint64) (*model.Account, error)
+	FindAccountByDocument(ctx context.Context, documentNumber string) (*model.Account, error)
+	UpdateCreditLimit(ctx context.Context, accountId uint64, limit money.Amount, reason string) (*model.Account, error)
+	UpdateStatus(ctx context.Context, accountId uint64, status string) (*model.Account, error)
 }
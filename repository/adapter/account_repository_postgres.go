@@ -3,33 +3,49 @@ package adapter
 import (
 	"context"
 	"database/sql"
-	"log"
-	"time"
+	"log/slog"
 
 	"github.com/aniljaiswalcs/pismo/model"
+	"github.com/aniljaiswalcs/pismo/pkg/logging"
+	"github.com/aniljaiswalcs/pismo/pkg/money"
 )
 
 type AccountRepositoryPostgres struct {
-	db *sql.DB
+	db       *sql.DB
+	timeouts Timeouts
 }
 
-func NewAccountRepositoryPostgres(db *sql.DB) *AccountRepositoryPostgres {
+func NewAccountRepositoryPostgres(db *sql.DB, timeouts Timeouts) *AccountRepositoryPostgres {
 	return &AccountRepositoryPostgres{
-		db: db,
+		db:       db,
+		timeouts: timeouts,
 	}
 }
 
+// CreateAccount stores a new account. When the document number already has
+// an account it returns that account together with
+// model.ErrDocumentNumberExists.
 func (a *AccountRepositoryPostgres) CreateAccount(ctx context.Context, account model.Account) (*model.Account, error) {
 
-	ctxTimeout, cancel := context.WithTimeout(ctx, 2*time.Second)
+	ctxTimeout, cancel := context.WithTimeout(ctx, a.timeouts.query())
 	defer cancel()
 
-	query := "INSERT INTO accounts (document_number) VALUES ($1) RETURNING account_id"
+	query := "INSERT INTO accounts (document_number, currency, available_credit_limit) VALUES ($1, $2, $3) ON CONFLICT (document_number) DO NOTHING RETURNING account_id, status"
 
-	err := a.db.QueryRowContext(ctxTimeout, query, account.DocumentNumber).Scan(&account.AccountId)
+	err := a.db.QueryRowContext(ctxTimeout, query, account.DocumentNumber, account.Currency, account.AvailableCreditLimit).Scan(&account.AccountId, &account.Status)
+	if err == sql.ErrNoRows {
+		existing := model.Account{}
+		query = "SELECT account_id, document_number, currency, status, available_credit_limit FROM accounts WHERE document_number=$1"
+		err = a.db.QueryRowContext(ctxTimeout, query, account.DocumentNumber).Scan(&existing.AccountId, &existing.DocumentNumber, &existing.Currency, &existing.Status, &existing.AvailableCreditLimit)
+		if err != nil {
+			slog.ErrorContext(ctx, "AccountRepositoryPostgres#CreateAccount: database query failed", logging.DocumentNumberKey, account.DocumentNumber, "error", err)
+			return nil, translateError(err)
+		}
+		return &existing, model.ErrDocumentNumberExists
+	}
 	if err != nil {
-		log.Printf("AccountRepositoryPostgres#CreateAccount: Database query (%s) failed: %s", query, err)
-		return nil, err
+		slog.ErrorContext(ctx, "AccountRepositoryPostgres#CreateAccount: database query failed", logging.DocumentNumberKey, account.DocumentNumber, "error", err)
+		return nil, translateError(err)
 	}
 
 	return &account, nil
@@ -38,18 +54,144 @@ func (a *AccountRepositoryPostgres) CreateAccount(ctx context.Context, account m
 
 func (a *AccountRepositoryPostgres) FindAccount(ctx context.Context, accountId uint64) (*model.Account, error) {
 
-	ctxTimeout, cancel := context.WithTimeout(ctx, 2*time.Second)
+	ctxTimeout, cancel := context.WithTimeout(ctx, a.timeouts.query())
 	defer cancel()
 
 	account := model.Account{}
-	query := "SELECT account_id, document_number FROM accounts WHERE account_id=$1 LIMIT 1"
+	query := "SELECT account_id, document_number, currency, status, available_credit_limit FROM accounts WHERE account_id=$1 LIMIT 1"
 	result := a.db.QueryRowContext(ctxTimeout, query, accountId)
-	err := result.Scan(&account.AccountId, &account.DocumentNumber)
+	err := result.Scan(&account.AccountId, &account.DocumentNumber, &account.Currency, &account.Status, &account.AvailableCreditLimit)
+	if err != nil {
+		slog.ErrorContext(ctx, "AccountRepositoryPostgres#FindAccount: database query failed", "error", err)
+
+		return nil, translateError(err)
+	}
+
+	return &account, nil
+}
+
+// FindAccountByDocument returns the account opened for the document number.
+// The lookup uses the index behind the unique constraint on document_number.
+func (a *AccountRepositoryPostgres) FindAccountByDocument(ctx context.Context, documentNumber string) (*model.Account, error) {
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, a.timeouts.query())
+	defer cancel()
+
+	account := model.Account{}
+	query := "SELECT account_id, document_number, currency, status, available_credit_limit FROM accounts WHERE document_number=$1"
+	result := a.db.QueryRowContext(ctxTimeout, query, documentNumber)
+	err := result.Scan(&account.AccountId, &account.DocumentNumber, &account.Currency, &account.Status, &account.AvailableCreditLimit)
+	if err != nil {
+		slog.ErrorContext(ctx, "AccountRepositoryPostgres#FindAccountByDocument: database query failed", logging.DocumentNumberKey, documentNumber, "error", err)
+
+		return nil, translateError(err)
+	}
+
+	return &account, nil
+}
+
+// UpdateCreditLimit sets the available credit limit of the account and
+// records the change in credit_limit_audits within the same database
+// transaction. It returns apperror.ErrNotFound when the account does not
+// exist.
+func (a *AccountRepositoryPostgres) UpdateCreditLimit(ctx context.Context, accountId uint64, limit money.Amount, reason string) (*model.Account, error) {
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, a.timeouts.query())
+	defer cancel()
+
+	tx, err := a.db.BeginTx(ctxTimeout, nil)
 	if err != nil {
-		log.Printf("AccountRepositoryPostgres#FindAccount: Database query (%s) failed: %s", query, err)
+		slog.ErrorContext(ctx, "AccountRepositoryPostgres#UpdateCreditLimit: begin transaction failed", "error", err)
+		return nil, translateError(err)
+	}
+	defer tx.Rollback()
+
+	account := model.Account{}
+	query := "SELECT account_id, document_number, currency, status, available_credit_limit FROM accounts WHERE account_id=$1 FOR UPDATE"
+	err = tx.QueryRowContext(ctxTimeout, query, accountId).Scan(&account.AccountId, &account.DocumentNumber, &account.Currency, &account.Status, &account.AvailableCreditLimit)
+	if err != nil {
+		slog.ErrorContext(ctx, "AccountRepositoryPostgres#UpdateCreditLimit: database query failed", "error", err)
+		return nil, translateError(err)
+	}
+
+	query = "INSERT INTO credit_limit_audits (account_id, previous_limit, new_limit, reason) VALUES ($1, $2, $3, $4)"
+	_, err = tx.ExecContext(ctxTimeout, query, accountId, account.AvailableCreditLimit, limit, reason)
+	if err != nil {
+		slog.ErrorContext(ctx, "AccountRepositoryPostgres#UpdateCreditLimit: database query failed", "error", err)
+		return nil, translateError(err)
+	}
+
+	query = "UPDATE accounts SET available_credit_limit = $1 WHERE account_id = $2"
+	_, err = tx.ExecContext(ctxTimeout, query, limit, accountId)
+	if err != nil {
+		slog.ErrorContext(ctx, "AccountRepositoryPostgres#UpdateCreditLimit: database query failed", "error", err)
+		return nil, translateError(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		slog.ErrorContext(ctx, "AccountRepositoryPostgres#UpdateCreditLimit: commit failed", "error", err)
+		return nil, translateError(err)
+	}
+
+	account.AvailableCreditLimit = &limit
+	return &account, nil
+}
+
+// UpdateStatus moves the account to a new status. The account row stays
+// locked while the transition is checked, so no transaction can add debt to
+// an account being closed. It returns apperror.ErrNotFound when the account
+// does not exist, model.ErrInvalidStatusTransition when the move is not
+// allowed and model.ErrOutstandingDebt when closing an account that still
+// owes money.
+func (a *AccountRepositoryPostgres) UpdateStatus(ctx context.Context, accountId uint64, status string) (*model.Account, error) {
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, a.timeouts.query())
+	defer cancel()
+
+	tx, err := a.db.BeginTx(ctxTimeout, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, "AccountRepositoryPostgres#UpdateStatus: begin transaction failed", "error", err)
+		return nil, translateError(err)
+	}
+	defer tx.Rollback()
+
+	account := model.Account{}
+	query := "SELECT account_id, document_number, currency, status, available_credit_limit FROM accounts WHERE account_id=$1 FOR UPDATE"
+	err = tx.QueryRowContext(ctxTimeout, query, accountId).Scan(&account.AccountId, &account.DocumentNumber, &account.Currency, &account.Status, &account.AvailableCreditLimit)
+	if err != nil {
+		slog.ErrorContext(ctx, "AccountRepositoryPostgres#UpdateStatus: database query failed", "error", err)
+		return nil, translateError(err)
+	}
+
+	if err = model.ValidateStatusTransition(account.Status, status); err != nil {
+		return nil, translateError(err)
+	}
+
+	if status == model.ACCOUNT_CLOSED {
+		var outstandingDebt bool
+		query = "SELECT EXISTS (SELECT 1 FROM transactions WHERE account_id = $1 AND balance < 0)"
+		err = tx.QueryRowContext(ctxTimeout, query, accountId).Scan(&outstandingDebt)
+		if err != nil {
+			slog.ErrorContext(ctx, "AccountRepositoryPostgres#UpdateStatus: database query failed", "error", err)
+			return nil, translateError(err)
+		}
+		if outstandingDebt {
+			return nil, model.ErrOutstandingDebt
+		}
+	}
+
+	query = "UPDATE accounts SET status = $1 WHERE account_id = $2"
+	_, err = tx.ExecContext(ctxTimeout, query, status, accountId)
+	if err != nil {
+		slog.ErrorContext(ctx, "AccountRepositoryPostgres#UpdateStatus: database query failed", "error", err)
+		return nil, translateError(err)
+	}
 
-		return nil, err
+	if err = tx.Commit(); err != nil {
+		slog.ErrorContext(ctx, "AccountRepositoryPostgres#UpdateStatus: commit failed", "error", err)
+		return nil, translateError(err)
 	}
 
+	account.Status = status
 	return &account, nil
 }
@@ -0,0 +1,73 @@
+package adapter
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"strings"
+
+	"github.com/lib/pq"
+
+	"github.com/aniljaiswalcs/pismo/pkg/apperror"
+)
+
+// translateError classifies errors coming from database/sql and lib/pq into
+// the apperror kinds, keeping the original error as the cause. Errors that
+// already have a kind, such as domain errors, are returned unchanged.
+func translateError(err error) error {
+	if err == nil || apperror.Kind(err) != nil {
+		return err
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return apperror.Wrap(apperror.ErrNotFound, err)
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return apperror.Wrap(apperror.ErrTimeout, err)
+	}
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) {
+		return apperror.Wrap(apperror.ErrUnavailable, err)
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return translatePqError(pqErr, err)
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return apperror.Wrap(apperror.ErrTimeout, err)
+		}
+		return apperror.Wrap(apperror.ErrUnavailable, err)
+	}
+
+	return err
+}
+
+// translatePqError classifies a Postgres error by its SQLSTATE code, see
+// https://www.postgresql.org/docs/current/errcodes-appendix.html.
+func translatePqError(pqErr *pq.Error, err error) error {
+	code := string(pqErr.Code)
+
+	switch {
+	case code == "23505":
+		// unique_violation
+		return apperror.Wrap(apperror.ErrConflict, err)
+	case strings.HasPrefix(code, "23"), strings.HasPrefix(code, "22"):
+		// integrity constraint violations, such as a foreign key to a missing
+		// operation type, and data exceptions, such as a numeric overflow
+		return apperror.Wrap(apperror.ErrValidation, err)
+	case code == "57014":
+		// query_canceled, sent when the context of the query is done
+		return apperror.Wrap(apperror.ErrTimeout, err)
+	case strings.HasPrefix(code, "08"), strings.HasPrefix(code, "53"), strings.HasPrefix(code, "57P"), strings.HasPrefix(code, "40"):
+		// connection exceptions, insufficient resources, server shutdown and
+		// serialization failures or deadlocks: all worth retrying later
+		return apperror.Wrap(apperror.ErrUnavailable, err)
+	}
+
+	return err
+}
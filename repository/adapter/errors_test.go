@@ -0,0 +1,58 @@
+package adapter
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/lib/pq"
+
+	"github.com/aniljaiswalcs/pismo/model"
+	"github.com/aniljaiswalcs/pismo/pkg/apperror"
+)
+
+func TestTranslateError(t *testing.T) {
+	var scenarios = []struct {
+		description  string
+		err          error
+		expectedKind error
+	}{
+		{"no rows", sql.ErrNoRows, apperror.ErrNotFound},
+		{"wrapped no rows", fmt.Errorf("scan: %w", sql.ErrNoRows), apperror.ErrNotFound},
+		{"deadline", context.DeadlineExceeded, apperror.ErrTimeout},
+		{"canceled", context.Canceled, apperror.ErrTimeout},
+		{"canceled statement", &pq.Error{Code: "57014"}, apperror.ErrTimeout},
+		{"unique violation", &pq.Error{Code: "23505"}, apperror.ErrConflict},
+		{"foreign key violation", &pq.Error{Code: "23503"}, apperror.ErrValidation},
+		{"check violation", &pq.Error{Code: "23514"}, apperror.ErrValidation},
+		{"numeric overflow", &pq.Error{Code: "22003"}, apperror.ErrValidation},
+		{"connection failure", &pq.Error{Code: "08006"}, apperror.ErrUnavailable},
+		{"server shutting down", &pq.Error{Code: "57P01"}, apperror.ErrUnavailable},
+		{"deadlock", &pq.Error{Code: "40P01"}, apperror.ErrUnavailable},
+		{"bad connection", driver.ErrBadConn, apperror.ErrUnavailable},
+		{"syntax error", &pq.Error{Code: "42601"}, nil},
+		{"unknown error", errors.New("boom"), nil},
+		{"domain error", model.ErrAccountBlocked, apperror.ErrValidation},
+	}
+
+	for _, scenario := range scenarios {
+		translated := translateError(scenario.err)
+
+		if kind := apperror.Kind(translated); kind != scenario.expectedKind {
+			t.Errorf("%s: expected kind %v but got %v", scenario.description, scenario.expectedKind, kind)
+		}
+		if !errors.Is(translated, scenario.err) {
+			t.Errorf("%s: expected the original error to be kept", scenario.description)
+		}
+	}
+
+	if translateError(nil) != nil {
+		t.Errorf("Expected nil to stay nil")
+	}
+	if translateError(model.ErrAccountBlocked) != model.ErrAccountBlocked {
+		t.Errorf("Expected domain errors to be returned unchanged")
+	}
+}
@@ -0,0 +1,64 @@
+package adapter
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+
+	"github.com/aniljaiswalcs/pismo/model"
+)
+
+type HealthRepositoryPostgres struct {
+	db       *sql.DB
+	timeouts Timeouts
+}
+
+func NewHealthRepositoryPostgres(db *sql.DB, timeouts Timeouts) *HealthRepositoryPostgres {
+	return &HealthRepositoryPostgres{
+		db:       db,
+		timeouts: timeouts,
+	}
+}
+
+func (h *HealthRepositoryPostgres) Ping(ctx context.Context) error {
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, h.timeouts.query())
+	defer cancel()
+
+	if err := h.db.PingContext(ctxTimeout); err != nil {
+		slog.ErrorContext(ctx, "HealthRepositoryPostgres#Ping: database ping failed", "error", err)
+		return translateError(err)
+	}
+
+	return nil
+}
+
+// SchemaVersion reads the table golang-migrate keeps its state in. It holds
+// a single row once the first migration has run.
+func (h *HealthRepositoryPostgres) SchemaVersion(ctx context.Context) (*model.SchemaVersion, error) {
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, h.timeouts.query())
+	defer cancel()
+
+	schemaVersion := model.SchemaVersion{}
+	query := "SELECT version, dirty FROM schema_migrations LIMIT 1"
+	err := h.db.QueryRowContext(ctxTimeout, query).Scan(&schemaVersion.Version, &schemaVersion.Dirty)
+	if err != nil {
+		slog.ErrorContext(ctx, "HealthRepositoryPostgres#SchemaVersion: database query failed", "error", err)
+		return nil, translateError(err)
+	}
+
+	return &schemaVersion, nil
+}
+
+func (h *HealthRepositoryPostgres) PoolStats() model.PoolStats {
+
+	stats := h.db.Stats()
+	return model.PoolStats{
+		MaxOpenConnections: stats.MaxOpenConnections,
+		OpenConnections:    stats.OpenConnections,
+		InUse:              stats.InUse,
+		Idle:               stats.Idle,
+		WaitCount:          stats.WaitCount,
+	}
+}
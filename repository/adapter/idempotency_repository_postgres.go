@@ -0,0 +1,147 @@
+package adapter
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+
+	"github.com/aniljaiswalcs/pismo/model"
+)
+
+// DefaultIdempotencyLease is used when the repository was built without a
+// lease.
+const DefaultIdempotencyLease = 15 * time.Second
+
+// maxReserveAttempts bounds how often ReserveKey tries again when the key it
+// found taken is released before it could be read.
+const maxReserveAttempts = 3
+
+type IdempotencyRepositoryPostgres struct {
+	db       *sql.DB
+	timeouts Timeouts
+	lease    time.Duration
+}
+
+// NewIdempotencyRepositoryPostgres builds the repository. lease is how long
+// a reservation without a response blocks its key; it must outlast the
+// longest request, since a request still running past it may run twice.
+func NewIdempotencyRepositoryPostgres(db *sql.DB, timeouts Timeouts, lease time.Duration) *IdempotencyRepositoryPostgres {
+	if lease <= 0 {
+		lease = DefaultIdempotencyLease
+	}
+
+	return &IdempotencyRepositoryPostgres{
+		db:       db,
+		timeouts: timeouts,
+		lease:    lease,
+	}
+}
+
+// ReserveKey stores a pending record for the key. A pending record older
+// than the lease was left by a request that never finished, for instance
+// because the process stopped, and is taken over by the new request.
+func (i *IdempotencyRepositoryPostgres) ReserveKey(ctx context.Context, record model.IdempotencyRecord) (*model.IdempotencyRecord, bool, error) {
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, i.timeouts.query())
+	defer cancel()
+
+	var err error
+	for attempt := 0; attempt < maxReserveAttempts; attempt++ {
+		var existing *model.IdempotencyRecord
+		var reserved bool
+		existing, reserved, err = i.reserveKey(ctxTimeout, record)
+		if err == nil {
+			return existing, reserved, nil
+		}
+		if err != sql.ErrNoRows {
+			return nil, false, translateError(err)
+		}
+	}
+
+	slog.ErrorContext(ctx, "IdempotencyRepositoryPostgres#ReserveKey: key kept being released", "attempts", maxReserveAttempts)
+	return nil, false, translateError(err)
+}
+
+// reserveKey makes one attempt at reserving the key. It returns
+// sql.ErrNoRows when the key was taken but released before it could be read.
+func (i *IdempotencyRepositoryPostgres) reserveKey(ctx context.Context, record model.IdempotencyRecord) (*model.IdempotencyRecord, bool, error) {
+
+	query := "INSERT INTO idempotency_keys (idempotency_key, scope, request_hash) VALUES ($1, $2, $3) ON CONFLICT (idempotency_key, scope) DO UPDATE SET request_hash = EXCLUDED.request_hash, created_at = NOW() WHERE idempotency_keys.status_code IS NULL AND idempotency_keys.created_at < NOW() - $4 * INTERVAL '1 millisecond' RETURNING created_at"
+	err := i.db.QueryRowContext(ctx, query, record.Key, record.Scope, record.RequestHash, i.lease.Milliseconds()).Scan(&record.CreatedAt)
+	if err == nil {
+		return &record, true, nil
+	}
+	if err != sql.ErrNoRows {
+		slog.ErrorContext(ctx, "IdempotencyRepositoryPostgres#ReserveKey: database query failed", "error", err)
+		return nil, false, err
+	}
+
+	existing := model.IdempotencyRecord{}
+	var statusCode sql.NullInt64
+	var contentType sql.NullString
+	query = "SELECT idempotency_key, scope, request_hash, status_code, content_type, response_body, created_at FROM idempotency_keys WHERE idempotency_key = $1 AND scope = $2"
+	err = i.db.QueryRowContext(ctx, query, record.Key, record.Scope).Scan(
+		&existing.Key,
+		&existing.Scope,
+		&existing.RequestHash,
+		&statusCode,
+		&contentType,
+		&existing.ResponseBody,
+		&existing.CreatedAt)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			slog.ErrorContext(ctx, "IdempotencyRepositoryPostgres#ReserveKey: database query failed", "error", err)
+		}
+		return nil, false, err
+	}
+	existing.StatusCode = int(statusCode.Int64)
+	existing.ContentType = contentType.String
+
+	return &existing, false, nil
+}
+
+func (i *IdempotencyRepositoryPostgres) CompleteKey(ctx context.Context, record model.IdempotencyRecord) error {
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, i.timeouts.query())
+	defer cancel()
+
+	query := "UPDATE idempotency_keys SET status_code = $1, content_type = $2, response_body = $3 WHERE idempotency_key = $4 AND scope = $5"
+	_, err := i.db.ExecContext(ctxTimeout, query, record.StatusCode, record.ContentType, record.ResponseBody, record.Key, record.Scope)
+	if err != nil {
+		slog.ErrorContext(ctx, "IdempotencyRepositoryPostgres#CompleteKey: database query failed", "error", err)
+		return translateError(err)
+	}
+
+	return nil
+}
+
+func (i *IdempotencyRepositoryPostgres) ReleaseKey(ctx context.Context, scope string, key string) error {
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, i.timeouts.query())
+	defer cancel()
+
+	query := "DELETE FROM idempotency_keys WHERE idempotency_key = $1 AND scope = $2"
+	_, err := i.db.ExecContext(ctxTimeout, query, key, scope)
+	if err != nil {
+		slog.ErrorContext(ctx, "IdempotencyRepositoryPostgres#ReleaseKey: database query failed", "error", err)
+		return translateError(err)
+	}
+
+	return nil
+}
+
+func (i *IdempotencyRepositoryPostgres) DeleteExpiredKeys(ctx context.Context, before time.Time) (int64, error) {
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, i.timeouts.transaction())
+	defer cancel()
+
+	query := "DELETE FROM idempotency_keys WHERE created_at < $1::timestamp"
+	result, err := i.db.ExecContext(ctxTimeout, query, before.UTC().Format(timestampLayout))
+	if err != nil {
+		slog.ErrorContext(ctx, "IdempotencyRepositoryPostgres#DeleteExpiredKeys: database query failed", "error", err)
+		return 0, translateError(err)
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,86 @@
+package adapter
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+
+	"github.com/aniljaiswalcs/pismo/model"
+)
+
+type OperationTypeRepositoryPostgres struct {
+	db       *sql.DB
+	timeouts Timeouts
+}
+
+func NewOperationTypeRepositoryPostgres(db *sql.DB, timeouts Timeouts) *OperationTypeRepositoryPostgres {
+	return &OperationTypeRepositoryPostgres{
+		db:       db,
+		timeouts: timeouts,
+	}
+}
+
+func (o *OperationTypeRepositoryPostgres) ListOperationTypes(ctx context.Context) ([]model.OperationType, error) {
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, o.timeouts.query())
+	defer cancel()
+
+	query := "SELECT operation_type_id, description, sign, dischargeable, consumes_credit_limit FROM operation_types ORDER BY operation_type_id"
+	rows, err := o.db.QueryContext(ctxTimeout, query)
+	if err != nil {
+		slog.ErrorContext(ctx, "OperationTypeRepositoryPostgres#ListOperationTypes: database query failed", "error", err)
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	operationTypes := []model.OperationType{}
+	for rows.Next() {
+		operationType := model.OperationType{}
+		err = rows.Scan(
+			&operationType.OperationTypeId,
+			&operationType.Description,
+			&operationType.Sign,
+			&operationType.Dischargeable,
+			&operationType.ConsumesCreditLimit)
+		if err != nil {
+			slog.ErrorContext(ctx, "OperationTypeRepositoryPostgres#ListOperationTypes: scan failed", "error", err)
+			return nil, translateError(err)
+		}
+		operationTypes = append(operationTypes, operationType)
+	}
+	if err = rows.Err(); err != nil {
+		slog.ErrorContext(ctx, "OperationTypeRepositoryPostgres#ListOperationTypes: database query failed", "error", err)
+		return nil, translateError(err)
+	}
+
+	return operationTypes, nil
+}
+
+// CreateOperationType stores a new operation type. It returns
+// model.ErrOperationTypeExists when the id is already taken.
+func (o *OperationTypeRepositoryPostgres) CreateOperationType(ctx context.Context, operationType model.OperationType) (*model.OperationType, error) {
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, o.timeouts.query())
+	defer cancel()
+
+	query := "INSERT INTO operation_types (operation_type_id, description, sign, dischargeable, consumes_credit_limit) VALUES ($1, $2, $3, $4, $5) ON CONFLICT (operation_type_id) DO NOTHING RETURNING operation_type_id"
+	err := o.db.QueryRowContext(
+		ctxTimeout,
+		query,
+		operationType.OperationTypeId,
+		operationType.Description,
+		operationType.Sign,
+		operationType.Dischargeable,
+		operationType.ConsumesCreditLimit).
+		Scan(&operationType.OperationTypeId)
+
+	if err == sql.ErrNoRows {
+		return nil, model.ErrOperationTypeExists
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "OperationTypeRepositoryPostgres#CreateOperationType: database query failed", "error", err)
+		return nil, translateError(err)
+	}
+
+	return &operationType, nil
+}
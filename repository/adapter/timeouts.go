@@ -0,0 +1,30 @@
+package adapter
+
+import "time"
+
+const (
+	DefaultQueryTimeout       = 2 * time.Second
+	DefaultTransactionTimeout = 4 * time.Second
+)
+
+// Timeouts bounds the database calls of a repository. Query covers single
+// statements and Transaction the longer database transactions; zero values
+// fall back to the defaults.
+type Timeouts struct {
+	Query       time.Duration
+	Transaction time.Duration
+}
+
+func (t Timeouts) query() time.Duration {
+	if t.Query <= 0 {
+		return DefaultQueryTimeout
+	}
+	return t.Query
+}
+
+func (t Timeouts) transaction() time.Duration {
+	if t.Transaction <= 0 {
+		return DefaultTransactionTimeout
+	}
+	return t.Transaction
+}
@@ -3,112 +3,507 @@ package adapter
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-	"log"
-	"time"
+	"log/slog"
+	"strconv"
+	"strings"
 
 	"github.com/aniljaiswalcs/pismo/model"
+	"github.com/aniljaiswalcs/pismo/pkg/fx"
+	"github.com/aniljaiswalcs/pismo/pkg/metrics"
+	"github.com/aniljaiswalcs/pismo/pkg/money"
 )
 
 type TransactionRepositoryPostgres struct {
-	db *sql.DB
+	db       *sql.DB
+	strategy model.DischargeStrategy
+	rates    fx.RateProvider
+	timeouts Timeouts
 }
 
-func NewTransactionRepositoryPostgres(db *sql.DB) *TransactionRepositoryPostgres {
+func NewTransactionRepositoryPostgres(db *sql.DB, strategy model.DischargeStrategy, rates fx.RateProvider, timeouts Timeouts) *TransactionRepositoryPostgres {
 	return &TransactionRepositoryPostgres{
-		db: db,
+		db:       db,
+		strategy: strategy,
+		rates:    rates,
+		timeouts: timeouts,
 	}
 }
 
+// queryer is satisfied by both *sql.DB and *sql.Tx so reads can run inside
+// or outside of a database transaction.
+type queryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// rowsQueryer is the multi-row counterpart of queryer.
+type rowsQueryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// CreateTransaction inserts the transaction and, for payments and other
+// credits, discharges the account's open debts. Installment purchases also
+// get their installment schedule. Everything runs in a single database
+// transaction so a failure at any step leaves no balance half-updated.
 func (t *TransactionRepositoryPostgres) CreateTransaction(ctx context.Context, transaction model.Transaction) (*model.Transaction, error) {
 
-	ctxTimeout, cancel := context.WithTimeout(ctx, 2*time.Second)
+	ctxTimeout, cancel := context.WithTimeout(ctx, t.timeouts.transaction())
 	defer cancel()
 
-	query := "INSERT INTO transactions (account_id, operation_type_id, amount, balance) VALUES ($1, $2, $3, $3) RETURNING transaction_id"
-	err := t.db.QueryRowContext(
+	tx, err := t.db.BeginTx(ctxTimeout, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, "TransactionRepositoryPostgres#CreateTransaction: begin transaction failed", "error", err)
+		return nil, translateError(err)
+	}
+	defer tx.Rollback()
+
+	account, err := t.lockAccount(ctxTimeout, tx, transaction.AccountId)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	accountCurrency := account.Currency
+
+	if err = model.AllowsTransaction(account.Status, transaction.OperationTypeId); err != nil {
+		return nil, translateError(err)
+	}
+
+	if transaction.Currency == "" {
+		transaction.Currency = accountCurrency
+	} else if transaction.Currency != accountCurrency {
+		// payments, reversals and refunds must be in the account currency to
+		// discharge its debts
+		if !model.ConsumesCreditLimit(transaction.OperationTypeId) {
+			return nil, model.ErrCurrencyMismatch
+		}
+
+		rate, err := t.rates.Rate(ctxTimeout, transaction.Currency, accountCurrency)
+		if err != nil {
+			slog.ErrorContext(ctx, "TransactionRepositoryPostgres#CreateTransaction: exchange rate lookup failed", "error", err)
+			if errors.Is(err, fx.ErrRateNotFound) {
+				return nil, model.ErrExchangeRateUnavailable
+			}
+			return nil, translateError(err)
+		}
+
+		transaction, err = transaction.ConvertTo(accountCurrency, rate)
+		if err != nil {
+			return nil, translateError(err)
+		}
+	}
+
+	// purchases and withdrawals consume the limit with their converted amount
+	if model.ConsumesCreditLimit(transaction.OperationTypeId) {
+		if !account.CoversSpending(transaction.Amount.Neg()) {
+			return nil, model.ErrInsufficientCreditLimit
+		}
+		err = t.adjustCreditLimit(ctxTimeout, tx, transaction.AccountId, transaction.Amount)
+		if err != nil {
+			return nil, translateError(err)
+		}
+	}
+
+	var original *model.Transaction
+	if model.RequiresOriginalTransaction(transaction.OperationTypeId) {
+		original, err = t.lockRefundableTransaction(ctxTimeout, tx, transaction)
+		if err != nil {
+			return nil, translateError(err)
+		}
+	}
+
+	// the debt of an installment purchase is carried by its installments
+	balance := transaction.Amount
+	if transaction.OperationTypeId == model.INSTALLMENT_PURCHASE {
+		balance = 0
+		if transaction.InstallmentCount == 0 {
+			transaction.InstallmentCount = 1
+		}
+	}
+
+	query := "INSERT INTO transactions (account_id, operation_type_id, amount, balance, currency, original_amount, original_currency, exchange_rate, original_transaction_id, installment_count) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING transaction_id, created_at"
+	err = tx.QueryRowContext(
 		ctxTimeout,
 		query,
 		transaction.AccountId,
 		transaction.OperationTypeId,
-		transaction.Amount).
-		Scan(&transaction.TransactionId)
+		transaction.Amount,
+		balance,
+		transaction.Currency,
+		transaction.OriginalAmount,
+		sql.NullString{String: transaction.OriginalCurrency, Valid: transaction.OriginalCurrency != ""},
+		transaction.ExchangeRate,
+		transaction.OriginalTransactionId,
+		sql.NullInt32{Int32: int32(transaction.InstallmentCount), Valid: transaction.InstallmentCount != 0}).
+		Scan(&transaction.TransactionId, &transaction.CreatedAt)
 
 	if err != nil {
-		log.Printf("TransactionRepositoryPostgres#CreateTransaction: Database query (%s) failed: %s", query, err)
-		return nil, err
+		slog.ErrorContext(ctx, "TransactionRepositoryPostgres#CreateTransaction: database query failed", "error", err)
+		return nil, translateError(err)
 	}
-	//update values
-	if transaction.OperationTypeId == 4 {
-		err := t.SubtractTransaction(ctx, transaction)
+
+	var discharge *model.DischargeResult
+	if transaction.OperationTypeId == model.INSTALLMENT_PURCHASE {
+		err = t.createInstallments(ctxTimeout, tx, model.InstallmentSchedule(transaction))
+		if err != nil {
+			return nil, translateError(err)
+		}
+	} else if original != nil {
+		discharge, err = t.refundTransaction(ctxTimeout, tx, transaction, *original)
 		if err != nil {
-			return nil, err
+			return nil, translateError(err)
+		}
+	} else if model.IsCredit(transaction.OperationTypeId) {
+		discharge, err = t.dischargeTransaction(ctxTimeout, tx, transaction)
+		if err != nil {
+			return nil, translateError(err)
 		}
 	}
-	// find the updated transaction valuues
-	transactionId, err := t.FindtransactionAccount(ctx, transaction.TransactionId)
+
+	// read the stored values back before committing so the caller never gets
+	// an error for a transaction that was actually persisted
+	created, err := t.findTransaction(ctxTimeout, tx, transaction.TransactionId)
 	if err != nil {
-		return nil, err
+		return nil, translateError(err)
+	}
+	if created.InstallmentCount > 0 {
+		created.Installments, err = t.findInstallments(ctxTimeout, tx, created.TransactionId)
+		if err != nil {
+			return nil, translateError(err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		slog.ErrorContext(ctx, "TransactionRepositoryPostgres#CreateTransaction: commit failed", "error", err)
+		return nil, translateError(err)
+	}
+
+	metrics.TransactionCreated(created.OperationTypeId)
+	if discharge != nil {
+		metrics.Discharged(created.Currency, created.Amount.Sub(discharge.Remaining), len(discharge.Discharged))
 	}
-	return transactionId, nil
+
+	return created, nil
 }
 
+// SubtractTransaction discharges the account's open debts with an already
+// stored payment in its own database transaction.
 func (t *TransactionRepositoryPostgres) SubtractTransaction(ctx context.Context, transaction model.Transaction) error {
 
-	ctxTimeout, cancel := context.WithTimeout(ctx, 2*time.Second)
+	ctxTimeout, cancel := context.WithTimeout(ctx, t.timeouts.transaction())
 	defer cancel()
 
-	// fetch amount using account id sort by time;
-	query := "SELECT transaction_id, balance, account_id, operation_type_id FROM transactions WHERE account_id = $1 AND operation_type_id < 4 order by created_at DESC"
+	tx, err := t.db.BeginTx(ctxTimeout, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, "TransactionRepositoryPostgres#SubtractTransaction: begin transaction failed", "error", err)
+		return translateError(err)
+	}
+	defer tx.Rollback()
+
+	discharge, err := t.dischargeTransaction(ctxTimeout, tx, transaction)
+	if err != nil {
+		return translateError(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		slog.ErrorContext(ctx, "TransactionRepositoryPostgres#SubtractTransaction: commit failed", "error", err)
+		return translateError(err)
+	}
+
+	metrics.Discharged(transaction.Currency, transaction.Amount.Sub(discharge.Remaining), len(discharge.Discharged))
+
+	return nil
+}
+
+// lockAccount reads the account and keeps its row locked until the database
+// transaction ends, serialising every transaction created for the account.
+func (t *TransactionRepositoryPostgres) lockAccount(ctx context.Context, tx *sql.Tx, accountId uint64) (*model.Account, error) {
+
+	account := model.Account{AccountId: accountId}
+	query := "SELECT currency, status, available_credit_limit FROM accounts WHERE account_id = $1 FOR UPDATE"
+	err := tx.QueryRowContext(ctx, query, accountId).Scan(&account.Currency, &account.Status, &account.AvailableCreditLimit)
+	if err != nil {
+		slog.ErrorContext(ctx, "TransactionRepositoryPostgres#lockAccount: database query failed", "error", err)
+		return nil, translateError(err)
+	}
+
+	return &account, nil
+}
+
+func (t *TransactionRepositoryPostgres) createInstallments(ctx context.Context, tx *sql.Tx, installments []model.Transaction) error {
+
+	query := "INSERT INTO transactions (account_id, operation_type_id, amount, balance, currency, parent_transaction_id, installment_number, due_date, created_at) VALUES ($1, $2, $3, $3, $4, $5, $6, $7, $8::timestamp)"
+
+	for _, installment := range installments {
+		_, err := tx.ExecContext(
+			ctx,
+			query,
+			installment.AccountId,
+			installment.OperationTypeId,
+			installment.Amount,
+			installment.Currency,
+			installment.ParentTransactionId,
+			installment.InstallmentNumber,
+			installment.DueDate.Format("2006-01-02"),
+			installment.CreatedAt.UTC().Format(timestampLayout))
+
+		if err != nil {
+			slog.ErrorContext(ctx, "TransactionRepositoryPostgres#createInstallments: database query failed", "error", err)
+			return translateError(err)
+		}
+	}
+
+	return nil
+}
+
+func (t *TransactionRepositoryPostgres) findInstallments(ctx context.Context, q rowsQueryer, parentTransactionId uint64) ([]model.Transaction, error) {
+
+	query := "SELECT " + transactionColumns + " FROM transactions WHERE parent_transaction_id = $1 ORDER BY installment_number"
+	rows, err := q.QueryContext(ctx, query, parentTransactionId)
+	if err != nil {
+		slog.ErrorContext(ctx, "TransactionRepositoryPostgres#findInstallments: database query failed", "error", err)
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	installments := []model.Transaction{}
+	for rows.Next() {
+		installment, err := scanTransaction(rows)
+		if err != nil {
+			slog.ErrorContext(ctx, "TransactionRepositoryPostgres#findInstallments: scan failed", "error", err)
+			return nil, translateError(err)
+		}
+		installments = append(installments, installment)
+	}
+	if err = rows.Err(); err != nil {
+		slog.ErrorContext(ctx, "TransactionRepositoryPostgres#findInstallments: database query failed", "error", err)
+		return nil, translateError(err)
+	}
+
+	return installments, nil
+}
+
+func (t *TransactionRepositoryPostgres) adjustCreditLimit(ctx context.Context, tx *sql.Tx, accountId uint64, delta money.Amount) error {
 
-	rows, err := t.db.Query(query, transaction.AccountId)
+	query := "UPDATE accounts SET available_credit_limit = available_credit_limit + $1 WHERE account_id = $2"
+	_, err := tx.ExecContext(ctx, query, delta, accountId)
 	if err != nil {
-		fmt.Println(" Error during SubtractTransaction query")
-		return nil
+		slog.ErrorContext(ctx, "TransactionRepositoryPostgres#adjustCreditLimit: database query failed", "error", err)
+		return translateError(err)
 	}
 
-	result := []model.Transaction{}
+	return nil
+}
+
+// dischargeTransaction locks the account's open debts and settles them with
+// the payment amount in the order of the configured discharge strategy.
+// Installments are only open debts once they are due.
+// Whatever is left stays as the payment balance, every settled amount is
+// recorded as a payment allocation and given back to the credit limit.
+// The result is returned so it can be reported once the caller commits.
+func (t *TransactionRepositoryPostgres) dischargeTransaction(ctx context.Context, tx *sql.Tx, transaction model.Transaction) (*model.DischargeResult, error) {
 
+	// rows are locked in primary key order so concurrent payments on the same
+	// account cannot deadlock; the strategy decides the discharge order
+	query := "SELECT transaction_id, balance, account_id, operation_type_id, currency, created_at FROM transactions WHERE account_id = $1 AND operation_type_id IN (SELECT operation_type_id FROM operation_types WHERE dischargeable) AND balance < 0 AND (due_date IS NULL OR due_date <= CURRENT_DATE) ORDER BY transaction_id FOR UPDATE"
+
+	rows, err := tx.QueryContext(ctx, query, transaction.AccountId)
+	if err != nil {
+		slog.ErrorContext(ctx, "TransactionRepositoryPostgres#SubtractTransaction: database query failed", "error", err)
+		return nil, translateError(err)
+	}
 	defer rows.Close()
+
+	debts := []model.Transaction{}
 	for rows.Next() {
-		res := model.Transaction{} // creating new struct for every row
-		err = rows.Scan(&res.TransactionId, &res.Balance, &res.AccountId, &res.OperationTypeId)
+		res := model.Transaction{}
+		err = rows.Scan(&res.TransactionId, &res.Balance, &res.AccountId, &res.OperationTypeId, &res.Currency, &res.CreatedAt)
 		if err != nil {
-			log.Println(err)
+			slog.ErrorContext(ctx, "TransactionRepositoryPostgres#SubtractTransaction: scan failed", "error", err)
+			return nil, translateError(err)
 		}
-		result = append(result, res) // add new row information
+		debts = append(debts, res)
+	}
+	if err = rows.Err(); err != nil {
+		slog.ErrorContext(ctx, "TransactionRepositoryPostgres#SubtractTransaction: database query failed", "error", err)
+		return nil, translateError(err)
+	}
+
+	result, err := model.Discharge(transaction, debts, t.strategy)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	if err = t.applyDischarge(ctx, tx, transaction, result); err != nil {
+		return nil, err
 	}
 
-	initialVal := transaction.Amount
-	for index, _ := range result {
-		res := result[index].Balance + initialVal
-		if res > 0 {
-			result[index].Balance = 0
-			initialVal = res
-		} else if res <= 0 {
-			initialVal = 0
-			break
+	return &result, nil
+}
+
+// lockRefundableTransaction locks the transaction a reversal or refund points
+// to and checks the new amount against what was already given back.
+func (t *TransactionRepositoryPostgres) lockRefundableTransaction(ctx context.Context, tx *sql.Tx, refund model.Transaction) (*model.Transaction, error) {
+
+	query := "SELECT " + transactionColumns + " FROM transactions WHERE transaction_id = $1 FOR UPDATE"
+	original, err := scanTransaction(tx.QueryRowContext(ctx, query, *refund.OriginalTransactionId))
+	if err == sql.ErrNoRows {
+		return nil, model.ErrOriginalTransactionNotFound
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "TransactionRepositoryPostgres#lockRefundableTransaction: database query failed", "error", err)
+		return nil, translateError(err)
+	}
+
+	var refunded money.Amount
+	query = "SELECT COALESCE(SUM(amount), 0) FROM transactions WHERE original_transaction_id = $1 AND operation_type_id IN ($2, $3)"
+	err = tx.QueryRowContext(ctx, query, original.TransactionId, model.REVERSAL, model.REFUND).Scan(&refunded)
+	if err != nil {
+		slog.ErrorContext(ctx, "TransactionRepositoryPostgres#lockRefundableTransaction: database query failed", "error", err)
+		return nil, translateError(err)
+	}
+
+	if err = model.ValidateRefund(refund, original, refunded); err != nil {
+		return nil, translateError(err)
+	}
+
+	return &original, nil
+}
+
+// refundTransaction gives the reversal or refund back to the open balance of
+// the original transaction. Installment purchases are given back through
+// their open installments, the last ones first, whether due or not.
+// Whatever the original no longer owes stays as the balance of the refund.
+func (t *TransactionRepositoryPostgres) refundTransaction(ctx context.Context, tx *sql.Tx, refund model.Transaction, original model.Transaction) (*model.DischargeResult, error) {
+
+	debts := []model.Transaction{original}
+	strategy := model.DischargeStrategy(model.OldestFirst{})
+	if original.InstallmentCount > 0 {
+		query := "SELECT " + transactionColumns + " FROM transactions WHERE parent_transaction_id = $1 AND balance < 0 ORDER BY transaction_id FOR UPDATE"
+		rows, err := tx.QueryContext(ctx, query, original.TransactionId)
+		if err != nil {
+			slog.ErrorContext(ctx, "TransactionRepositoryPostgres#refundTransaction: database query failed", "error", err)
+			return nil, translateError(err)
+		}
+		defer rows.Close()
+
+		debts = []model.Transaction{}
+		for rows.Next() {
+			installment, err := scanTransaction(rows)
+			if err != nil {
+				slog.ErrorContext(ctx, "TransactionRepositoryPostgres#refundTransaction: scan failed", "error", err)
+				return nil, translateError(err)
+			}
+			debts = append(debts, installment)
+		}
+		if err = rows.Err(); err != nil {
+			slog.ErrorContext(ctx, "TransactionRepositoryPostgres#refundTransaction: database query failed", "error", err)
+			return nil, translateError(err)
 		}
+		strategy = model.NewestFirst{}
+	}
+
+	result, err := model.Discharge(refund, debts, strategy)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	if err = t.applyDischarge(ctx, tx, refund, result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// applyDischarge stores the outcome of a discharge: the new balances, one
+// allocation per settled debt and the settled amount given back to the
+// credit limit.
+func (t *TransactionRepositoryPostgres) applyDischarge(ctx context.Context, tx *sql.Tx, transaction model.Transaction, result model.DischargeResult) error {
+
+	transaction.Balance = result.Remaining
+	err := t.UpdateTransactiondatabse(ctx, tx, result.Discharged, transaction)
+	if err != nil {
+		return translateError(err)
 	}
-	transaction.Balance = initialVal
-	err = t.UpdateTransactiondatabse(ctxTimeout, result, transaction)
+
+	err = t.createPaymentAllocations(ctx, tx, result.Allocations)
 	if err != nil {
-		fmt.Println(err)
-		return nil
+		return translateError(err)
 	}
+
+	return t.adjustCreditLimit(ctx, tx, transaction.AccountId, transaction.Amount.Sub(result.Remaining))
+}
+
+func (t *TransactionRepositoryPostgres) createPaymentAllocations(ctx context.Context, tx *sql.Tx, allocations []model.PaymentAllocation) error {
+
+	query := "INSERT INTO payment_allocations (payment_transaction_id, debt_transaction_id, amount) VALUES ($1, $2, $3)"
+
+	for _, allocation := range allocations {
+		_, err := tx.ExecContext(
+			ctx,
+			query,
+			allocation.PaymentTransactionId,
+			allocation.DebtTransactionId,
+			allocation.Amount)
+
+		if err != nil {
+			slog.ErrorContext(ctx, "TransactionRepositoryPostgres#createPaymentAllocations: database query failed", "error", err)
+			return translateError(err)
+		}
+	}
+
 	return nil
 }
 
-func (t *TransactionRepositoryPostgres) UpdateTransactiondatabse(ctx context.Context, result []model.Transaction, initialtransaction model.Transaction) error {
+// FindPaymentAllocations returns the allocations the transaction takes part
+// in, either as the payment or as the settled debt. It returns
+// apperror.ErrNotFound when the transaction does not exist.
+func (t *TransactionRepositoryPostgres) FindPaymentAllocations(ctx context.Context, transactionId uint64) ([]model.PaymentAllocation, error) {
 
-	ctxTimeout, cancel := context.WithTimeout(ctx, 5*time.Second)
+	ctxTimeout, cancel := context.WithTimeout(ctx, t.timeouts.query())
 	defer cancel()
 
+	if _, err := t.findTransaction(ctxTimeout, t.db, transactionId); err != nil {
+		return nil, translateError(err)
+	}
+
+	query := "SELECT allocation_id, payment_transaction_id, debt_transaction_id, amount, created_at FROM payment_allocations WHERE payment_transaction_id = $1 OR debt_transaction_id = $1 ORDER BY allocation_id"
+	rows, err := t.db.QueryContext(ctxTimeout, query, transactionId)
+	if err != nil {
+		slog.ErrorContext(ctx, "TransactionRepositoryPostgres#FindPaymentAllocations: database query failed", "error", err)
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	allocations := []model.PaymentAllocation{}
+	for rows.Next() {
+		allocation := model.PaymentAllocation{}
+		err = rows.Scan(
+			&allocation.AllocationId,
+			&allocation.PaymentTransactionId,
+			&allocation.DebtTransactionId,
+			&allocation.Amount,
+			&allocation.CreatedAt)
+		if err != nil {
+			slog.ErrorContext(ctx, "TransactionRepositoryPostgres#FindPaymentAllocations: scan failed", "error", err)
+			return nil, translateError(err)
+		}
+		allocations = append(allocations, allocation)
+	}
+	if err = rows.Err(); err != nil {
+		slog.ErrorContext(ctx, "TransactionRepositoryPostgres#FindPaymentAllocations: database query failed", "error", err)
+		return nil, translateError(err)
+	}
+
+	return allocations, nil
+}
+
+func (t *TransactionRepositoryPostgres) UpdateTransactiondatabse(ctx context.Context, tx *sql.Tx, result []model.Transaction, initialtransaction model.Transaction) error {
+
 	query := "UPDATE transactions set balance = $1 where transaction_id = $2 AND account_id = $3 AND operation_type_id = $4"
 
-	for _, res := range result {
-		_, err := t.db.ExecContext(
-			ctxTimeout,
+	for _, res := range append(result, initialtransaction) {
+		_, err := tx.ExecContext(
+			ctx,
 			query,
 			res.Balance,
 			res.TransactionId,
@@ -116,42 +511,230 @@ func (t *TransactionRepositoryPostgres) UpdateTransactiondatabse(ctx context.Con
 			res.OperationTypeId)
 
 		if err != nil {
-			log.Printf("TransactionRepositoryPostgres#UpdateTransaction: Database query (%s) failed: %s", query, err)
-			return nil
+			slog.ErrorContext(ctx, "TransactionRepositoryPostgres#UpdateTransaction: database query failed", "error", err)
+			return translateError(err)
 		}
 	}
 
-	// update initial balance
-	_, err := t.db.ExecContext(
-		ctxTimeout,
-		query,
-		initialtransaction.Balance,
-		initialtransaction.TransactionId,
-		initialtransaction.AccountId,
-		initialtransaction.OperationTypeId)
+	return nil
+}
+
+func (t *TransactionRepositoryPostgres) FindtransactionAccount(ctx context.Context, transactionid uint64) (*model.Transaction, error) {
 
+	ctxTimeout, cancel := context.WithTimeout(ctx, t.timeouts.query())
+	defer cancel()
+
+	transaction, err := t.findTransaction(ctxTimeout, t.db, transactionid)
 	if err != nil {
-		log.Printf("TransactionRepositoryPostgres#CreateTransaction: Database query (%s) failed: %s", query, err)
-		return nil
+		return nil, translateError(err)
+	}
+	if transaction.InstallmentCount > 0 {
+		transaction.Installments, err = t.findInstallments(ctxTimeout, t.db, transaction.TransactionId)
+		if err != nil {
+			return nil, translateError(err)
+		}
 	}
 
-	return nil
+	return transaction, nil
 }
 
-func (t *TransactionRepositoryPostgres) FindtransactionAccount(ctx context.Context, transactionid uint64) (*model.Transaction, error) {
+const transactionColumns = "account_id, operation_type_id, amount, balance, currency, original_amount, COALESCE(original_currency, ''), exchange_rate, original_transaction_id, COALESCE(installment_count, 0), parent_transaction_id, COALESCE(installment_number, 0), due_date, transaction_id, created_at"
 
-	ctxTimeout, cancel := context.WithTimeout(ctx, 2*time.Second)
-	defer cancel()
+// rowScanner is satisfied by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
 
+func scanTransaction(row rowScanner) (model.Transaction, error) {
 	transaction := model.Transaction{}
-	query := "SELECT account_id, operation_type_id, amount,balance, transaction_id FROM transactions WHERE transaction_id=$1 LIMIT 1"
-	result := t.db.QueryRowContext(ctxTimeout, query, transactionid)
-	err := result.Scan(&transaction.AccountId, &transaction.OperationTypeId, &transaction.Amount, &transaction.Balance, &transaction.TransactionId)
+	err := row.Scan(
+		&transaction.AccountId,
+		&transaction.OperationTypeId,
+		&transaction.Amount,
+		&transaction.Balance,
+		&transaction.Currency,
+		&transaction.OriginalAmount,
+		&transaction.OriginalCurrency,
+		&transaction.ExchangeRate,
+		&transaction.OriginalTransactionId,
+		&transaction.InstallmentCount,
+		&transaction.ParentTransactionId,
+		&transaction.InstallmentNumber,
+		&transaction.DueDate,
+		&transaction.TransactionId,
+		&transaction.CreatedAt)
+
+	return transaction, err
+}
+
+func (t *TransactionRepositoryPostgres) findTransaction(ctx context.Context, q queryer, transactionid uint64) (*model.Transaction, error) {
+
+	query := "SELECT " + transactionColumns + " FROM transactions WHERE transaction_id=$1 LIMIT 1"
+	transaction, err := scanTransaction(q.QueryRowContext(ctx, query, transactionid))
 	if err != nil {
-		log.Printf("transactionRepositoryPostgres#FindAccount: Database query (%s) failed: %s", query, err)
+		slog.ErrorContext(ctx, "transactionRepositoryPostgres#FindAccount: database query failed", "error", err)
 
-		return nil, err
+		return nil, translateError(err)
 	}
 
 	return &transaction, nil
 }
+
+// ListTransactions returns one page of the account history using keyset
+// pagination on (created_at, transaction_id). It returns
+// apperror.ErrNotFound when the account does not exist.
+func (t *TransactionRepositoryPostgres) ListTransactions(ctx context.Context, accountId uint64, filter model.TransactionFilter) (*model.TransactionPage, error) {
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, t.timeouts.query())
+	defer cancel()
+
+	var exists bool
+	query := "SELECT EXISTS (SELECT 1 FROM accounts WHERE account_id = $1)"
+	if err := t.db.QueryRowContext(ctxTimeout, query, accountId).Scan(&exists); err != nil {
+		slog.ErrorContext(ctx, "TransactionRepositoryPostgres#ListTransactions: database query failed", "error", err)
+		return nil, translateError(err)
+	}
+	if !exists {
+		return nil, translateError(sql.ErrNoRows)
+	}
+
+	query, args := buildListTransactionsQuery(accountId, filter)
+	rows, err := t.db.QueryContext(ctxTimeout, query, args...)
+	if err != nil {
+		slog.ErrorContext(ctx, "TransactionRepositoryPostgres#ListTransactions: database query failed", "error", err)
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	page := &model.TransactionPage{Transactions: []model.Transaction{}}
+	for rows.Next() {
+		transaction, err := scanTransaction(rows)
+		if err != nil {
+			slog.ErrorContext(ctx, "TransactionRepositoryPostgres#ListTransactions: scan failed", "error", err)
+			return nil, translateError(err)
+		}
+		page.Transactions = append(page.Transactions, transaction)
+	}
+	if err = rows.Err(); err != nil {
+		slog.ErrorContext(ctx, "TransactionRepositoryPostgres#ListTransactions: database query failed", "error", err)
+		return nil, translateError(err)
+	}
+
+	// one extra row was requested to know whether another page exists
+	if len(page.Transactions) > filter.Limit {
+		page.Transactions = page.Transactions[:filter.Limit]
+		page.NextCursor = model.CursorOf(page.Transactions[filter.Limit-1]).Encode()
+	}
+
+	return page, nil
+}
+
+// created_at is a timestamp without time zone holding UTC wall time, so time
+// values are sent in that same format and cast explicitly.
+const timestampLayout = "2006-01-02 15:04:05.999999"
+
+func buildListTransactionsQuery(accountId uint64, filter model.TransactionFilter) (string, []interface{}) {
+	args := []interface{}{accountId}
+	conditions := []string{"account_id = $1"}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	if filter.OperationTypeId != 0 {
+		conditions = append(conditions, "operation_type_id = "+arg(filter.OperationTypeId))
+	}
+	if filter.CreatedFrom != nil {
+		conditions = append(conditions, "created_at >= "+arg(filter.CreatedFrom.UTC().Format(timestampLayout))+"::timestamp")
+	}
+	if filter.CreatedTo != nil {
+		conditions = append(conditions, "created_at < "+arg(filter.CreatedTo.UTC().Format(timestampLayout))+"::timestamp")
+	}
+	if filter.MinAmount != nil {
+		conditions = append(conditions, "amount >= "+arg(*filter.MinAmount))
+	}
+	if filter.MaxAmount != nil {
+		conditions = append(conditions, "amount <= "+arg(*filter.MaxAmount))
+	}
+	if filter.OpenOnly {
+		conditions = append(conditions, "balance <> 0")
+	}
+
+	order, comparison := "DESC", "<"
+	if filter.Sort == model.SORT_ASC {
+		order, comparison = "ASC", ">"
+	}
+	if filter.After != nil {
+		conditions = append(conditions, fmt.Sprintf(
+			"(created_at, transaction_id) %s (%s::timestamp, %s)",
+			comparison,
+			arg(filter.After.CreatedAt.UTC().Format(timestampLayout)),
+			arg(filter.After.TransactionId)))
+	}
+
+	query := fmt.Sprintf(
+		"SELECT %s FROM transactions WHERE %s ORDER BY created_at %s, transaction_id %s LIMIT %s",
+		transactionColumns,
+		strings.Join(conditions, " AND "),
+		order,
+		order,
+		arg(filter.Limit+1))
+
+	return query, args
+}
+
+// GetAccountBalance sums the open balances of the account per operation type.
+// Both reads share one repeatable read snapshot and transactions are only
+// ever committed fully discharged, so the summary is consistent even while
+// other transactions are being created. It returns apperror.ErrNotFound when
+// the account does not exist.
+func (t *TransactionRepositoryPostgres) GetAccountBalance(ctx context.Context, accountId uint64) (*model.AccountBalance, error) {
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, t.timeouts.query())
+	defer cancel()
+
+	tx, err := t.db.BeginTx(ctxTimeout, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		slog.ErrorContext(ctx, "TransactionRepositoryPostgres#GetAccountBalance: begin transaction failed", "error", err)
+		return nil, translateError(err)
+	}
+	defer tx.Rollback()
+
+	account := model.Account{AccountId: accountId}
+	query := "SELECT currency FROM accounts WHERE account_id = $1"
+	err = tx.QueryRowContext(ctxTimeout, query, accountId).Scan(&account.Currency)
+	if err != nil {
+		slog.ErrorContext(ctx, "TransactionRepositoryPostgres#GetAccountBalance: database query failed", "error", err)
+		return nil, translateError(err)
+	}
+
+	query = "SELECT operation_type_id, COALESCE(-SUM(balance) FILTER (WHERE balance < 0), 0), COALESCE(SUM(balance) FILTER (WHERE balance > 0), 0), COUNT(*) FILTER (WHERE balance <> 0) FROM transactions WHERE account_id = $1 GROUP BY operation_type_id ORDER BY operation_type_id"
+	rows, err := tx.QueryContext(ctxTimeout, query, accountId)
+	if err != nil {
+		slog.ErrorContext(ctx, "TransactionRepositoryPostgres#GetAccountBalance: database query failed", "error", err)
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	operationTypes := []model.OperationTypeBalance{}
+	for rows.Next() {
+		operationType := model.OperationTypeBalance{}
+		err = rows.Scan(
+			&operationType.OperationTypeId,
+			&operationType.OutstandingDebt,
+			&operationType.PaymentSurplus,
+			&operationType.OpenTransactions)
+		if err != nil {
+			slog.ErrorContext(ctx, "TransactionRepositoryPostgres#GetAccountBalance: scan failed", "error", err)
+			return nil, translateError(err)
+		}
+		operationTypes = append(operationTypes, operationType)
+	}
+	if err = rows.Err(); err != nil {
+		slog.ErrorContext(ctx, "TransactionRepositoryPostgres#GetAccountBalance: database query failed", "error", err)
+		return nil, translateError(err)
+	}
+
+	balance := model.NewAccountBalance(account, operationTypes)
+	return &balance, nil
+}
@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/aniljaiswalcs/pismo/model"
+)
+
+type HealthRepository interface {
+	Ping(ctx context.Context) error
+	SchemaVersion(ctx context.Context) (*model.SchemaVersion, error)
+	PoolStats() model.PoolStats
+}
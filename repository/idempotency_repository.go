@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/aniljaiswalcs/pismo/model"
+)
+
+type IdempotencyRepository interface {
+	// ReserveKey stores a pending record for the key. When the key is already
+	// taken it returns the existing record and false. Pending records left
+	// behind by requests that never finished are taken over once they expire.
+	ReserveKey(ctx context.Context, record model.IdempotencyRecord) (*model.IdempotencyRecord, bool, error)
+	CompleteKey(ctx context.Context, record model.IdempotencyRecord) error
+	ReleaseKey(ctx context.Context, scope string, key string) error
+	DeleteExpiredKeys(ctx context.Context, before time.Time) (int64, error)
+}
@@ -0,0 +1,12 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/aniljaiswalcs/pismo/model"
+)
+
+type OperationTypeRepository interface {
+	ListOperationTypes(ctx context.Context) ([]model.OperationType, error)
+	CreateOperationType(ctx context.Context, operationType model.OperationType) (*model.OperationType, error)
+}
@@ -10,4 +10,7 @@ type TransactionRepository interface {
 	CreateTransaction(context.Context, model.Transaction) (*model.Transaction, error)
 	SubtractTransaction(context.Context, model.Transaction) error
 	FindtransactionAccount(ctx context.Context, transactionId uint64) (*model.Transaction, error)
+	FindPaymentAllocations(ctx context.Context, transactionId uint64) ([]model.PaymentAllocation, error)
+	ListTransactions(ctx context.Context, accountId uint64, filter model.TransactionFilter) (*model.TransactionPage, error)
+	GetAccountBalance(ctx context.Context, accountId uint64) (*model.AccountBalance, error)
 }